@@ -1,15 +1,28 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strings"
 	"sweatsparks/internal/config"
 	"sweatsparks/internal/factory"
 	"sweatsparks/internal/routes"
+	"sweatsparks/internal/search"
+	"sweatsparks/internal/services"
 	websockets "sweatsparks/internal/websocket"
 	"sweatsparks/pkg/database"
+	"sweatsparks/pkg/mailer"
+	"sweatsparks/pkg/presence"
+	"sweatsparks/pkg/storage"
+	"sweatsparks/pkg/token"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
@@ -19,14 +32,131 @@ func main() {
 		log.Fatal("Could not connect to MySQL:", err)
 	}
 
+	store, err := storage.NewLocalStorage(config.ENV.StorageDir)
+	if err != nil {
+		log.Fatal("Could not prepare attachment storage:", err)
+	}
+
+	broker, presenceStore, err := newBroker()
+	if err != nil {
+		log.Fatal("Could not connect to hub broker:", err)
+	}
+
+	searchIndex, err := newSearchIndex(mysqlDB)
+	if err != nil {
+		log.Fatal("Could not prepare message search:", err)
+	}
+
+	tokens, err := newKeyring()
+	if err != nil {
+		log.Fatal("Could not load JWT signing keys:", err)
+	}
+
+	mail, err := newMailer()
+	if err != nil {
+		log.Fatal("Could not prepare mailer:", err)
+	}
+
 	router := mux.NewRouter()
-	hub := websockets.NewHub()
-	go hub.Run()
 
-	provider := factory.InitFactory(mysqlDB)
-	routes.RegisterRoutes(mysqlDB, router, hub, provider)
+	provider := factory.InitFactory(mysqlDB, store, broker, presenceStore, searchIndex, tokens, mail)
+	go provider.Hub.Run()
+	go provider.Presence.Run()
+	go sweepExpiredMessages(provider.MessageExpiry, config.ENV.MessageSweepPeriod)
+
+	routes.RegisterRoutes(router, provider)
 
 	log.Printf("Server running on :%s\n", config.ENV.ServerPort)
 	log.Fatal(http.ListenAndServe(":"+config.ENV.ServerPort, router))
 
 }
+
+// sweepExpiredMessages deletes disappearing messages once they expire.
+func sweepExpiredMessages(expiry services.MessageExpiryService, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		deleted, err := expiry.SweepExpiredMessages(context.Background())
+		if err != nil {
+			log.Printf("error sweeping expired messages: %s", err.Message)
+		}
+		if deleted > 0 {
+			log.Printf("Deleted %d expired messages\n", deleted)
+		}
+	}
+}
+
+// newBroker picks how hub instances share deliveries and presence.
+func newBroker() (websockets.Broker, presence.Store, error) {
+	switch config.ENV.BrokerDriver {
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     config.ENV.RedisAddr,
+			Password: config.ENV.RedisPassword,
+			DB:       config.ENV.RedisDB,
+		})
+		if err := client.Ping(context.Background()).Err(); err != nil {
+			return nil, nil, err
+		}
+		presenceStore, err := presence.NewRedisStore(client, config.ENV.RedisPresencePrefix)
+		if err != nil {
+			return nil, nil, err
+		}
+		return websockets.NewRedisBroker(client, config.ENV.RedisChannel), presenceStore, nil
+	case "memory", "":
+		return websockets.NewMemoryBroker(), presence.NewMemoryStore(), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown broker driver %q", config.ENV.BrokerDriver)
+	}
+}
+
+func newSearchIndex(db *sql.DB) (search.Index, error) {
+	switch config.ENV.SearchDriver {
+	case "mysql", "":
+		return search.NewMySQLIndex(db), nil
+	case "memory":
+		return search.NewMemoryIndex(), nil
+	default:
+		return nil, fmt.Errorf("unknown search driver %q", config.ENV.SearchDriver)
+	}
+}
+
+func newMailer() (mailer.Mailer, error) {
+	switch config.ENV.MailDriver {
+	case "smtp":
+		return mailer.NewSMTPMailer(config.ENV.SMTPHost, config.ENV.SMTPPort, config.ENV.SMTPUsername, config.ENV.SMTPPassword, config.ENV.MailFrom), nil
+	case "file", "":
+		return mailer.NewFileMailer(config.ENV.MailDir, config.ENV.MailFrom)
+	case "memory":
+		return mailer.NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", config.ENV.MailDriver)
+	}
+}
+
+// newKeyring loads every key listed in JWT_KEYS as kid:ALG:path.
+func newKeyring() (*token.Keyring, error) {
+	var keys []*token.Key
+	for _, entry := range strings.Split(config.ENV.JWTKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("JWT_KEYS entry %q is not kid:ALG:path", entry)
+		}
+		material, err := os.ReadFile(parts[2])
+		if err != nil {
+			return nil, err
+		}
+		key, err := token.ParseKey(parts[0], parts[1], material)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return token.NewKeyring(config.ENV.JWTSigningKeyID, keys...)
+}
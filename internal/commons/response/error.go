@@ -43,6 +43,48 @@ var (
 		Status:     false,
 		Message:    "BAD REQUEST ERROR",
 	}
+	duplicateReportError = CustomError{
+		Code:       "ERR0006",
+		StatusCode: http.StatusConflict,
+		Status:     false,
+		Message:    "DUPLICATE REPORT",
+	}
+	invalidReportError = CustomError{
+		Code:       "ERR0007",
+		StatusCode: http.StatusBadRequest,
+		Status:     false,
+		Message:    "INVALID REPORT",
+	}
+	accountSuspendedError = CustomError{
+		Code:       "ERR0008",
+		StatusCode: http.StatusForbidden,
+		Status:     false,
+		Message:    "ACCOUNT SUSPENDED",
+	}
+	accountBannedError = CustomError{
+		Code:       "ERR0009",
+		StatusCode: http.StatusForbidden,
+		Status:     false,
+		Message:    "ACCOUNT BANNED",
+	}
+	forbiddenError = CustomError{
+		Code:       "ERR0010",
+		StatusCode: http.StatusForbidden,
+		Status:     false,
+		Message:    "FORBIDDEN",
+	}
+	tooManyRequestsError = CustomError{
+		Code:       "ERR0011",
+		StatusCode: http.StatusTooManyRequests,
+		Status:     false,
+		Message:    "TOO MANY REQUESTS",
+	}
+	emailNotVerifiedError = CustomError{
+		Code:       "ERR0012",
+		StatusCode: http.StatusForbidden,
+		Status:     false,
+		Message:    "EMAIL NOT VERIFIED",
+	}
 )
 
 func GeneralError(message ...string) *CustomError {
@@ -127,3 +169,68 @@ func BadRequestErrorWithAdditionalInfo(info interface{}, message ...string) *Cus
 	}
 	return &err
 }
+
+func DuplicateReportError(message ...string) *CustomError {
+	err := duplicateReportError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func InvalidReportError(message ...string) *CustomError {
+	err := invalidReportError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func AccountSuspendedError(message ...string) *CustomError {
+	err := accountSuspendedError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func AccountSuspendedErrorWithAdditionalInfo(info interface{}, message ...string) *CustomError {
+	err := accountSuspendedError
+	err.AdditionalInfo = info
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func AccountBannedError(message ...string) *CustomError {
+	err := accountBannedError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func ForbiddenError(message ...string) *CustomError {
+	err := forbiddenError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func TooManyRequestsError(message ...string) *CustomError {
+	err := tooManyRequestsError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func EmailNotVerifiedError(message ...string) *CustomError {
+	err := emailNotVerifiedError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
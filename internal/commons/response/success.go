@@ -44,3 +44,10 @@ func CreatedSuccessWithPayload(payload interface{}) *Response {
 	succ.Payload = payload
 	return &succ
 }
+
+func CreatedSuccessCustomMessageAndPayload(message string, payload interface{}) *Response {
+	succ := createSuccess
+	succ.Message = message
+	succ.Payload = payload
+	return &succ
+}
@@ -1,16 +1,45 @@
 package config
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	DBHost         string `mapstructure:"DB_HOST"`
-	DBUserName     string `mapstructure:"DB_USERNAME"`
-	DBUserPassword string `mapstructure:"DB_PASSWORD"`
-	DBName         string `mapstructure:"DB_DATABASE"`
-	DBPort         string `mapstructure:"DB_PORT"`
-	ServerPort     string `mapstructure:"PORT"`
+	DBHost              string        `mapstructure:"DB_HOST"`
+	DBUserName          string        `mapstructure:"DB_USERNAME"`
+	DBUserPassword      string        `mapstructure:"DB_PASSWORD"`
+	DBName              string        `mapstructure:"DB_DATABASE"`
+	DBPort              string        `mapstructure:"DB_PORT"`
+	ServerPort          string        `mapstructure:"PORT"`
+	TrustedProxies      string        `mapstructure:"TRUSTED_PROXIES"`
+	StorageDir          string        `mapstructure:"STORAGE_DIR"`
+	AttachmentMaxBytes  int64         `mapstructure:"ATTACHMENT_MAX_BYTES"`
+	MessageUnsendWindow time.Duration `mapstructure:"MESSAGE_UNSEND_WINDOW"`
+	MessageSweepPeriod  time.Duration `mapstructure:"MESSAGE_SWEEP_PERIOD"`
+	SearchDriver        string        `mapstructure:"SEARCH_DRIVER"`
+	BrokerDriver        string        `mapstructure:"BROKER_DRIVER"`
+	RedisAddr           string        `mapstructure:"REDIS_ADDR"`
+	RedisPassword       string        `mapstructure:"REDIS_PASSWORD"`
+	RedisDB             int           `mapstructure:"REDIS_DB"`
+	RedisChannel        string        `mapstructure:"REDIS_CHANNEL"`
+	RedisPresencePrefix string        `mapstructure:"REDIS_PRESENCE_PREFIX"`
+	AccessTokenTTL      time.Duration `mapstructure:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL     time.Duration `mapstructure:"REFRESH_TOKEN_TTL"`
+	JWTSigningKeyID     string        `mapstructure:"JWT_SIGNING_KEY_ID"`
+	JWTKeys             string        `mapstructure:"JWT_KEYS"`
+	MailDriver          string        `mapstructure:"MAIL_DRIVER"`
+	MailFrom            string        `mapstructure:"MAIL_FROM"`
+	MailDir             string        `mapstructure:"MAIL_DIR"`
+	SMTPHost            string        `mapstructure:"SMTP_HOST"`
+	SMTPPort            string        `mapstructure:"SMTP_PORT"`
+	SMTPUsername        string        `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword        string        `mapstructure:"SMTP_PASSWORD"`
+	EmailVerifyURL      string        `mapstructure:"EMAIL_VERIFY_URL"`
+	EmailVerifyTTL      time.Duration `mapstructure:"EMAIL_VERIFY_TTL"`
+	EmailVerifyCooldown time.Duration `mapstructure:"EMAIL_VERIFY_RESEND_COOLDOWN"`
+	EmailVerifyRequired string        `mapstructure:"EMAIL_VERIFY_REQUIRED_FOR"`
 }
 
 var ENV *Config
@@ -22,6 +51,26 @@ func LoadConfig() {
 	fang.SetConfigName(".env")
 	fang.SetConfigType("env")
 
+	fang.SetDefault("TRUSTED_PROXIES", "")
+	fang.SetDefault("STORAGE_DIR", "storage")
+	fang.SetDefault("ATTACHMENT_MAX_BYTES", 10<<20)
+	fang.SetDefault("MESSAGE_UNSEND_WINDOW", "1h")
+	fang.SetDefault("MESSAGE_SWEEP_PERIOD", "1m")
+	fang.SetDefault("SEARCH_DRIVER", "mysql")
+	fang.SetDefault("BROKER_DRIVER", "memory")
+	fang.SetDefault("REDIS_CHANNEL", "sweatsparks:hub")
+	fang.SetDefault("REDIS_PRESENCE_PREFIX", "sweatsparks:presence")
+	fang.SetDefault("ACCESS_TOKEN_TTL", "15m")
+	fang.SetDefault("REFRESH_TOKEN_TTL", "720h")
+	fang.SetDefault("MAIL_DRIVER", "file")
+	fang.SetDefault("MAIL_FROM", "Sweatsparks <no-reply@sweatsparks.local>")
+	fang.SetDefault("MAIL_DIR", "storage/mail")
+	fang.SetDefault("SMTP_PORT", "587")
+	fang.SetDefault("EMAIL_VERIFY_URL", "http://localhost:3000/verify-email")
+	fang.SetDefault("EMAIL_VERIFY_TTL", "24h")
+	fang.SetDefault("EMAIL_VERIFY_RESEND_COOLDOWN", "1m")
+	fang.SetDefault("EMAIL_VERIFY_REQUIRED_FOR", "swipe,chat")
+
 	err := fang.ReadInConfig()
 	if err != nil {
 		panic(err)
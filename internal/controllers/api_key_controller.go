@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type APIKeyController interface {
+	CreateAPIKey(w http.ResponseWriter, r *http.Request)
+	GetAPIKeys(w http.ResponseWriter, r *http.Request)
+	RevokeAPIKey(w http.ResponseWriter, r *http.Request)
+}
+
+type APIKeyControllerImpl struct {
+	APIKeyService services.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService services.APIKeyService) APIKeyController {
+	return &APIKeyControllerImpl{
+		APIKeyService: apiKeyService,
+	}
+}
+
+func (controller *APIKeyControllerImpl) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	actorID, ok := userIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req params.APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp := response.BadRequestError("Invalid input")
+		w.WriteHeader(resp.StatusCode)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	req.CreatedBy = actorID
+
+	result, err := controller.APIKeyService.CreateAPIKey(r.Context(), &req)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.CreatedSuccessCustomMessageAndPayload("Success create api key", result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *APIKeyControllerImpl) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	result, err := controller.APIKeyService.GetAPIKeys(r.Context(), r.URL.Query().Get("partner"))
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get api keys", result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *APIKeyControllerImpl) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	keyID, _ := strconv.ParseUint(vars["keyID"], 10, 64)
+
+	result, err := controller.APIKeyService.RevokeAPIKey(r.Context(), keyID)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success revoke api key", result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
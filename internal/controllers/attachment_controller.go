@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// multipartOverhead leaves room for the multipart envelope on top of the
+// attachment size limit when capping the request body.
+const multipartOverhead = 1 << 20
+
+type AttachmentController interface {
+	UploadAttachment(w http.ResponseWriter, r *http.Request)
+	DownloadAttachment(w http.ResponseWriter, r *http.Request)
+}
+
+type AttachmentControllerImpl struct {
+	AttachmentService services.AttachmentService
+	MaxBytes          int64
+}
+
+func NewAttachmentController(attachmentService services.AttachmentService, maxBytes int64) AttachmentController {
+	return &AttachmentControllerImpl{
+		AttachmentService: attachmentService,
+		MaxBytes:          maxBytes,
+	}
+}
+
+func (controller *AttachmentControllerImpl) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, ok := userIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	matchID, _ := strconv.ParseUint(vars["matchID"], 10, 64)
+
+	r.Body = http.MaxBytesReader(w, r.Body, controller.MaxBytes+multipartOverhead)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		resp := response.BadRequestError("Invalid input")
+		w.WriteHeader(resp.StatusCode)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	defer file.Close()
+
+	result, custErr := controller.AttachmentService.UploadAttachment(r.Context(), &params.AttachmentUploadRequest{
+		MatchID:    matchID,
+		UploaderID: uint64(userID),
+		FileName:   header.Filename,
+		Size:       header.Size,
+		File:       file,
+	})
+	if custErr != nil {
+		w.WriteHeader(custErr.StatusCode)
+		json.NewEncoder(w).Encode(custErr)
+		return
+	}
+
+	resp := response.CreatedSuccessCustomMessageAndPayload("Success upload attachment", result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *AttachmentControllerImpl) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	matchID, _ := strconv.Atoi(vars["matchID"])
+	attachmentID, _ := strconv.Atoi(vars["attachmentID"])
+
+	result, err := controller.AttachmentService.GetAttachment(r.Context(), matchID, attachmentID, int(userID))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+	defer result.Content.Close()
+
+	w.Header().Set("Content-Type", result.Attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(result.Attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": result.Attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, result.Content)
+}
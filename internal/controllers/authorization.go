@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/middleware"
+)
+
+// userIDFromRequest returns the authenticated caller. When there is none it
+// writes the 401 itself and returns false.
+func userIDFromRequest(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	userID, custErr := middleware.ActingUserID(r.Context())
+	if custErr != nil {
+		writeCustomError(w, custErr)
+		return 0, false
+	}
+	return userID, true
+}
+
+// viewerIDFromRequest is userIDFromRequest for the read endpoints partners
+// may call as well. A partner's API key views as no user in particular, 0.
+func viewerIDFromRequest(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	if _, ok := middleware.APIKeyFromContext(r.Context()); ok {
+		return 0, true
+	}
+	return userIDFromRequest(w, r)
+}
+
+// authorizeUser checks that the caller is userID, such as the user named in
+// the route. When it is not it writes the error itself and returns false.
+func authorizeUser(w http.ResponseWriter, r *http.Request, userID uint64) bool {
+	if custErr := middleware.AuthorizeUser(r.Context(), userID); custErr != nil {
+		writeCustomError(w, custErr)
+		return false
+	}
+	return true
+}
+
+func writeCustomError(w http.ResponseWriter, err *response.CustomError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.StatusCode)
+	json.NewEncoder(w).Encode(err)
+}
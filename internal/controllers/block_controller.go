@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type BlockController interface {
+	BlockUser(w http.ResponseWriter, r *http.Request)
+	UnblockUser(w http.ResponseWriter, r *http.Request)
+	GetBlockedUsers(w http.ResponseWriter, r *http.Request)
+}
+
+type BlockControllerImpl struct {
+	BlockService services.BlockService
+}
+
+func NewBlockController(blockService services.BlockService) BlockController {
+	return &BlockControllerImpl{
+		BlockService: blockService,
+	}
+}
+
+func (controller *BlockControllerImpl) BlockUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	req, ok := blockRequestFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := controller.BlockService.BlockUser(r.Context(), req)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.CreatedSuccessCustomMessageAndPayload("Success block user", result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *BlockControllerImpl) UnblockUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	req, ok := blockRequestFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	err := controller.BlockService.UnblockUser(r.Context(), req)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success unblock user", map[string]uint64{
+		"user_id": req.BlockedID,
+	})
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *BlockControllerImpl) GetBlockedUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, ok := userIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := controller.BlockService.GetBlockedUsers(r.Context(), int(userID))
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get blocked users", result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func blockRequestFromRequest(w http.ResponseWriter, r *http.Request) (*params.BlockRequest, bool) {
+	userID, ok := userIDFromRequest(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	vars := mux.Vars(r)
+	blockedID, _ := strconv.ParseUint(vars["userID"], 10, 64)
+
+	return &params.BlockRequest{
+		BlockerID: uint64(userID),
+		BlockedID: blockedID,
+	}, true
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/export"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type ExportController interface {
+	ExportConversation(w http.ResponseWriter, r *http.Request)
+}
+
+type ExportControllerImpl struct {
+	ExportService services.ExportService
+}
+
+func NewExportController(exportService services.ExportService) ExportController {
+	return &ExportControllerImpl{
+		ExportService: exportService,
+	}
+}
+
+func (controller *ExportControllerImpl) ExportConversation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	matchID, _ := strconv.ParseUint(vars["matchID"], 10, 64)
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = export.FormatJSON
+	}
+
+	writer, err := export.NewWriter(format, w)
+	if err != nil {
+		resp := response.BadRequestError("Unsupported export format")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.StatusCode)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	// Headers can still be replaced below as long as the service fails
+	// before writing anything.
+	w.Header().Set("Content-Type", writer.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="match-%d.%s"`, matchID, format))
+
+	custErr := controller.ExportService.ExportConversation(r.Context(), &params.ConversationExportRequest{
+		MatchID: matchID,
+		UserID:  uint64(userID),
+		Format:  format,
+	}, writer)
+	if custErr != nil {
+		w.Header().Del("Content-Disposition")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(custErr.StatusCode)
+		json.NewEncoder(w).Encode(custErr)
+	}
+}
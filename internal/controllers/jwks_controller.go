@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sweatsparks/pkg/token"
+)
+
+type JWKSController interface {
+	GetJWKS(w http.ResponseWriter, r *http.Request)
+}
+
+type JWKSControllerImpl struct {
+	Tokens *token.Keyring
+}
+
+func NewJWKSController(tokens *token.Keyring) JWKSController {
+	return &JWKSControllerImpl{
+		Tokens: tokens,
+	}
+}
+
+// GetJWKS publishes the public signing keys as a bare JWK set, without the
+// usual response envelope, since that is the shape JWT libraries fetch.
+func (controller *JWKSControllerImpl) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(controller.Tokens.JWKS())
+}
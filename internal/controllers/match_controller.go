@@ -5,15 +5,12 @@ import (
 	"net/http"
 	"strconv"
 	"sweatsparks/internal/commons/response"
-	"sweatsparks/internal/middleware"
-	"sweatsparks/internal/params"
 	"sweatsparks/internal/services"
 
 	"github.com/gorilla/mux"
 )
 
 type MatchController interface {
-	CreateMatch(w http.ResponseWriter, r *http.Request)
 	GetDetailMatchUser(w http.ResponseWriter, r *http.Request)
 	GetAllMatchUser(w http.ResponseWriter, r *http.Request)
 }
@@ -28,37 +25,10 @@ func NewMatchController(matchService services.MatchService) MatchController {
 	}
 }
 
-func (controller *MatchControllerImpl) CreateMatch(w http.ResponseWriter, r *http.Request) {
-	var req params.MatchRequest
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		resp := response.BadRequestError("Invalid input")
-		w.WriteHeader(resp.StatusCode)
-		json.NewEncoder(w).Encode(resp)
-		return
-	}
-
-	_, err := controller.MatchService.CreateMatchUser(r.Context(), &req)
-	if err != nil {
-		w.WriteHeader(err.StatusCode)
-		json.NewEncoder(w).Encode(err)
-		return
-	}
-
-	resp := response.CreatedSuccessWithPayload("Success create new a match")
-
-	w.WriteHeader(resp.StatusCode)
-	json.NewEncoder(w).Encode(resp)
-}
-
 func (controller *MatchControllerImpl) GetDetailMatchUser(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	userID, ok := middleware.UserIDFromContext(r.Context())
+	userID, ok := userIDFromRequest(w, r)
 	if !ok {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{
-			"errors": "Unauthorized",
-		})
 		return
 	}
 
@@ -81,12 +51,8 @@ func (controller *MatchControllerImpl) GetDetailMatchUser(w http.ResponseWriter,
 
 func (controller *MatchControllerImpl) GetAllMatchUser(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	userID, ok := middleware.UserIDFromContext(r.Context())
+	userID, ok := userIDFromRequest(w, r)
 	if !ok {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{
-			"errors": "Unauthorized",
-		})
 		return
 	}
 
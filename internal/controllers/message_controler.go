@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/params"
 	"sweatsparks/internal/services"
 
 	"github.com/gorilla/mux"
@@ -12,6 +13,12 @@ import (
 
 type MessageController interface {
 	GetMessageByMatchID(w http.ResponseWriter, r *http.Request)
+	EditMessage(w http.ResponseWriter, r *http.Request)
+	GetMessageEdits(w http.ResponseWriter, r *http.Request)
+	UnsendMessage(w http.ResponseWriter, r *http.Request)
+	DeleteMessage(w http.ResponseWriter, r *http.Request)
+	UpdateDisappearing(w http.ResponseWriter, r *http.Request)
+	SearchMessages(w http.ResponseWriter, r *http.Request)
 }
 
 type MessageControllerImpl struct {
@@ -26,12 +33,27 @@ func NewMessageController(messageService services.MessageService) MessageControl
 
 func (controller *MessageControllerImpl) GetMessageByMatchID(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	userID, ok := userIDFromRequest(w, r)
+	if !ok {
+		return
+	}
 
 	vars := mux.Vars(r)
 	matchIDStr := vars["matchID"]
-	matchID, _ := strconv.Atoi(matchIDStr)
+	matchID, _ := strconv.ParseUint(matchIDStr, 10, 64)
 
-	message, err := controller.MessageService.GetMessageByMatchId(r.Context(), matchID)
+	query := r.URL.Query()
+	before, _ := strconv.ParseUint(query.Get("before"), 10, 64)
+	after, _ := strconv.ParseUint(query.Get("after"), 10, 64)
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	message, err := controller.MessageService.GetMessageByMatchId(r.Context(), &params.MessageHistoryRequest{
+		MatchID: matchID,
+		UserID:  uint64(userID),
+		Before:  before,
+		After:   after,
+		Limit:   limit,
+	})
 	if err != nil {
 		w.WriteHeader(err.StatusCode)
 		json.NewEncoder(w).Encode(err)
@@ -43,3 +65,177 @@ func (controller *MessageControllerImpl) GetMessageByMatchID(w http.ResponseWrit
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(resp)
 }
+
+func (controller *MessageControllerImpl) EditMessage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	target, ok := messageDeleteRequestFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req params.MessageEditRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp := response.BadRequestError("Invalid input")
+		w.WriteHeader(resp.StatusCode)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	req.MatchID = target.MatchID
+	req.MessageID = target.MessageID
+	req.UserID = target.UserID
+
+	message, err := controller.MessageService.EditMessage(r.Context(), &req)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success edit message", message)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *MessageControllerImpl) GetMessageEdits(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	req, ok := messageDeleteRequestFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	edits, err := controller.MessageService.GetMessageEdits(r.Context(), req)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get data message edits", edits)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *MessageControllerImpl) UnsendMessage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	req, ok := messageDeleteRequestFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	message, err := controller.MessageService.UnsendMessage(r.Context(), req)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success unsend message", message)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *MessageControllerImpl) DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	req, ok := messageDeleteRequestFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	err := controller.MessageService.DeleteMessageForMe(r.Context(), req)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success delete message", &params.MessageDeletedResponse{
+		MessageID: req.MessageID,
+		MatchID:   req.MatchID,
+	})
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *MessageControllerImpl) UpdateDisappearing(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, ok := userIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req params.DisappearingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp := response.BadRequestError("Invalid input")
+		w.WriteHeader(resp.StatusCode)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	vars := mux.Vars(r)
+	req.MatchID, _ = strconv.ParseUint(vars["matchID"], 10, 64)
+	req.UserID = uint64(userID)
+
+	result, err := controller.MessageService.UpdateDisappearing(r.Context(), &req)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success update disappearing messages", result)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *MessageControllerImpl) SearchMessages(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, ok := userIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	result, err := controller.MessageService.SearchMessages(r.Context(), &params.MessageSearchRequest{
+		UserID: uint64(userID),
+		Query:  query.Get("q"),
+		Page:   page,
+		Limit:  limit,
+	})
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success search messages", result)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// messageDeleteRequestFromRequest reads the caller and the match and message
+// IDs from the route. It writes the 401 itself when there is no caller.
+func messageDeleteRequestFromRequest(w http.ResponseWriter, r *http.Request) (*params.MessageDeleteRequest, bool) {
+	userID, ok := userIDFromRequest(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	vars := mux.Vars(r)
+	matchID, _ := strconv.ParseUint(vars["matchID"], 10, 64)
+	messageID, _ := strconv.ParseUint(vars["messageID"], 10, 64)
+
+	return &params.MessageDeleteRequest{
+		MatchID:   matchID,
+		MessageID: messageID,
+		UserID:    uint64(userID),
+	}, true
+}
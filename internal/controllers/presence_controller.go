@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/services"
+)
+
+type PresenceController interface {
+	GetPresence(w http.ResponseWriter, r *http.Request)
+}
+
+type PresenceControllerImpl struct {
+	PresenceService services.PresenceService
+}
+
+func NewPresenceController(presenceService services.PresenceService) PresenceController {
+	return &PresenceControllerImpl{
+		PresenceService: presenceService,
+	}
+}
+
+func (controller *PresenceControllerImpl) GetPresence(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, ok := userIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var userIDs []uint64
+	for _, idStr := range strings.Split(r.URL.Query().Get("user_ids"), ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(idStr), 10, 64)
+		if err != nil {
+			continue
+		}
+		userIDs = append(userIDs, id)
+	}
+	if len(userIDs) == 0 {
+		resp := response.BadRequestError("user_ids is required")
+		w.WriteHeader(resp.StatusCode)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	result, err := controller.PresenceService.GetPresence(r.Context(), int(userID), userIDs)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get presence", result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
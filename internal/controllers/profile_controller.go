@@ -14,6 +14,7 @@ import (
 type ProfileController interface {
 	CreateProfile(w http.ResponseWriter, r *http.Request)
 	GetDetailProfile(w http.ResponseWriter, r *http.Request)
+	GetModerationProfile(w http.ResponseWriter, r *http.Request)
 	GetAllProfile(w http.ResponseWriter, r *http.Request)
 	UpdateProfile(w http.ResponseWriter, r *http.Request)
 }
@@ -53,10 +54,15 @@ func (controller *ProfileControllerImpl) CreateProfile(w http.ResponseWriter, r
 func (controller *ProfileControllerImpl) GetDetailProfile(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	viewerID, ok := viewerIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
 	vars := mux.Vars(r)
 	userIDStr := vars["userID"]
 	userID, _ := strconv.Atoi(userIDStr)
-	result, err := controller.ProfileService.GetProfileUser(r.Context(), userID)
+	result, err := controller.ProfileService.GetProfileUser(r.Context(), int(viewerID), userID)
 	if err != nil {
 		w.WriteHeader(err.StatusCode)
 		json.NewEncoder(w).Encode(err)
@@ -68,17 +74,36 @@ func (controller *ProfileControllerImpl) GetDetailProfile(w http.ResponseWriter,
 	json.NewEncoder(w).Encode(resp)
 }
 
-func (controller *ProfileControllerImpl) GetAllProfile(w http.ResponseWriter, r *http.Request) {
+func (controller *ProfileControllerImpl) GetModerationProfile(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	vars := mux.Vars(r)
-	userIDStr := vars["userID"]
-	userID, _ := strconv.Atoi(userIDStr)
+	userID, _ := strconv.Atoi(vars["userID"])
+	result, err := controller.ProfileService.GetProfileForModeration(r.Context(), userID)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
 
-	location := vars["location"]
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get data detail profile", result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *ProfileControllerImpl) GetAllProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := viewerIDFromRequest(w, r)
+	if !ok {
+		return
+	}
 
-	gender := vars["gender"]
-	result, err := controller.ProfileService.GetAllProfileUser(r.Context(), userID, gender, location)
+	query := r.URL.Query()
+	location := query.Get("location")
+
+	gender := query.Get("gender")
+	result, err := controller.ProfileService.GetAllProfileUser(r.Context(), int(userID), gender, location)
 	if err != nil {
 		w.WriteHeader(err.StatusCode)
 		json.NewEncoder(w).Encode(err)
@@ -92,6 +117,12 @@ func (controller *ProfileControllerImpl) GetAllProfile(w http.ResponseWriter, r
 
 func (controller *ProfileControllerImpl) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	vars := mux.Vars(r)
+	userID, _ := strconv.ParseUint(vars["userID"], 10, 64)
+	if !authorizeUser(w, r, userID) {
+		return
+	}
+
 	var req params.ProfileRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		resp := response.BadRequestError("Invalid input")
@@ -99,6 +130,9 @@ func (controller *ProfileControllerImpl) UpdateProfile(w http.ResponseWriter, r
 		json.NewEncoder(w).Encode(resp)
 		return
 	}
+	if req.UserID == 0 {
+		req.UserID = userID
+	}
 
 	_, err := controller.ProfileService.UpdateProfileUser(r.Context(), &req)
 	if err != nil {
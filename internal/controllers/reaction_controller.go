@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/services"
+)
+
+type ReactionController interface {
+	AddReaction(w http.ResponseWriter, r *http.Request)
+	RemoveReaction(w http.ResponseWriter, r *http.Request)
+}
+
+type ReactionControllerImpl struct {
+	ReactionService services.ReactionService
+}
+
+func NewReactionController(reactionService services.ReactionService) ReactionController {
+	return &ReactionControllerImpl{
+		ReactionService: reactionService,
+	}
+}
+
+func (controller *ReactionControllerImpl) AddReaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	target, ok := messageDeleteRequestFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req params.ReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp := response.BadRequestError("Invalid input")
+		w.WriteHeader(resp.StatusCode)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	req.MatchID = target.MatchID
+	req.MessageID = target.MessageID
+	req.UserID = target.UserID
+
+	result, err := controller.ReactionService.AddReaction(r.Context(), &req)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success add reaction", result)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *ReactionControllerImpl) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	target, ok := messageDeleteRequestFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	err := controller.ReactionService.RemoveReaction(r.Context(), &params.ReactionRequest{
+		MatchID:   target.MatchID,
+		MessageID: target.MessageID,
+		UserID:    target.UserID,
+	})
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success remove reaction", &params.MessageDeletedResponse{
+		MessageID: target.MessageID,
+		MatchID:   target.MatchID,
+	})
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
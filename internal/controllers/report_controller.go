@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/models"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type ReportController interface {
+	CreateReport(w http.ResponseWriter, r *http.Request)
+	GetReports(w http.ResponseWriter, r *http.Request)
+	GetReport(w http.ResponseWriter, r *http.Request)
+	ReviewReport(w http.ResponseWriter, r *http.Request)
+}
+
+type ReportControllerImpl struct {
+	ReportService services.ReportService
+}
+
+func NewReportController(reportService services.ReportService) ReportController {
+	return &ReportControllerImpl{
+		ReportService: reportService,
+	}
+}
+
+func (controller *ReportControllerImpl) CreateReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, ok := userIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req params.ReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp := response.BadRequestError("Invalid input")
+		w.WriteHeader(resp.StatusCode)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	req.ReporterID = uint64(userID)
+
+	result, err := controller.ReportService.CreateReport(r.Context(), &req)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.CreatedSuccessCustomMessageAndPayload("Success create report", result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *ReportControllerImpl) GetReports(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	status := query.Get("status")
+	if status == "" {
+		status = models.ReportStatusOpen
+	}
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	result, err := controller.ReportService.GetReports(r.Context(), &params.ReportListRequest{
+		Status: status,
+		Page:   page,
+		Limit:  limit,
+	})
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get reports", result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *ReportControllerImpl) GetReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	reportID, _ := strconv.ParseUint(vars["reportID"], 10, 64)
+
+	result, err := controller.ReportService.GetReport(r.Context(), reportID)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get report", result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *ReportControllerImpl) ReviewReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, ok := userIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req params.ReportReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp := response.BadRequestError("Invalid input")
+		w.WriteHeader(resp.StatusCode)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	vars := mux.Vars(r)
+	req.ReportID, _ = strconv.ParseUint(vars["reportID"], 10, 64)
+	req.ReviewerID = uint64(userID)
+
+	result, err := controller.ReportService.ReviewReport(r.Context(), &req)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success review report", result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
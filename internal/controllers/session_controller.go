@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type SessionController interface {
+	GetSessions(w http.ResponseWriter, r *http.Request)
+	RevokeSession(w http.ResponseWriter, r *http.Request)
+	RevokeOtherSessions(w http.ResponseWriter, r *http.Request)
+}
+
+type SessionControllerImpl struct {
+	SessionService services.SessionService
+}
+
+func NewSessionController(sessionService services.SessionService) SessionController {
+	return &SessionControllerImpl{
+		SessionService: sessionService,
+	}
+}
+
+func (controller *SessionControllerImpl) GetSessions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	result, err := controller.SessionService.GetSessions(r.Context())
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get sessions", result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *SessionControllerImpl) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	sessionID, err := strconv.ParseUint(mux.Vars(r)["sessionID"], 10, 64)
+	if err != nil {
+		resp := response.BadRequestError("Invalid session ID")
+		w.WriteHeader(resp.StatusCode)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	custErr := controller.SessionService.RevokeSession(r.Context(), sessionID)
+	if custErr != nil {
+		w.WriteHeader(custErr.StatusCode)
+		json.NewEncoder(w).Encode(custErr)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success revoke session", map[string]uint64{
+		"id": sessionID,
+	})
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *SessionControllerImpl) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	result, err := controller.SessionService.RevokeOtherSessions(r.Context())
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success revoke other sessions", result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// TrustedProxies are the reverse proxies whose X-Forwarded-For header is
+// believed. Anyone else could put any address in it.
+type TrustedProxies struct {
+	networks []*net.IPNet
+}
+
+// NewTrustedProxies reads a comma separated list of addresses and CIDR
+// ranges. Entries that are neither are logged and skipped.
+func NewTrustedProxies(list string) *TrustedProxies {
+	proxies := &TrustedProxies{}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			if v4 := ip.To4(); v4 != nil && !strings.Contains(entry, ":") {
+				ip = v4
+			}
+			bits := len(ip) * 8
+			proxies.networks = append(proxies.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Printf("ignoring trusted proxy %q: %v", entry, err)
+			continue
+		}
+		proxies.networks = append(proxies.networks, network)
+	}
+	return proxies
+}
+
+func (proxies *TrustedProxies) trusts(ip net.IP) bool {
+	for _, network := range proxies.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the address a request came from. Coming through trusted
+// proxies, that is the last X-Forwarded-For entry not added by one of them;
+// otherwise, or when an entry is not an IP address, it is the peer address.
+func (proxies *TrustedProxies) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	remote := net.ParseIP(host)
+	if remote == nil {
+		return ""
+	}
+	if !proxies.trusts(remote) {
+		return remote.String()
+	}
+
+	client := remote
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			return remote.String()
+		}
+		client = ip
+		if !proxies.trusts(ip) {
+			break
+		}
+	}
+	return client.String()
+}
+
+const maxUserAgentLength = 255
+
+// sessionDevice fills in where a login or refresh came from.
+func sessionDevice(r *http.Request, proxies *TrustedProxies, device *params.SessionDevice) {
+	device.UserAgent = r.UserAgent()
+	if len(device.UserAgent) > maxUserAgentLength {
+		device.UserAgent = device.UserAgent[:maxUserAgentLength]
+	}
+	device.IPAddress = proxies.clientIP(r)
+}
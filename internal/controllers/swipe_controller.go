@@ -37,14 +37,14 @@ func (controller *SwipeControllerImpl) CreateSwipe(w http.ResponseWriter, r *htt
 		return
 	}
 
-	_, err := controller.SwipeService.CreateSwipe(r.Context(), &req)
+	result, err := controller.SwipeService.CreateSwipe(r.Context(), &req)
 	if err != nil {
 		w.WriteHeader(err.StatusCode)
 		json.NewEncoder(w).Encode(err)
 		return
 	}
 
-	resp := response.CreatedSuccessWithPayload("Success insert swipe")
+	resp := response.CreatedSuccessCustomMessageAndPayload("Success insert swipe", result)
 
 	w.WriteHeader(resp.StatusCode)
 	json.NewEncoder(w).Encode(resp)
@@ -56,7 +56,7 @@ func (controller *SwipeControllerImpl) GetSwipeDetail(w http.ResponseWriter, r *
 	swiperIDStr := vars["swiperID"]
 	swiperID, _ := strconv.Atoi(swiperIDStr)
 
-	swipeeIDStr := vars["swiperID"]
+	swipeeIDStr := vars["swipeeID"]
 	swipeeID, _ := strconv.Atoi(swipeeIDStr)
 
 	result, err := controller.SwipeService.GetSwipeBySwiperAndSwipee(r.Context(), swiperID, swipeeID)
@@ -1,26 +1,44 @@
 package controllers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/middleware"
 	"sweatsparks/internal/params"
 	"sweatsparks/internal/services"
+
+	"github.com/gorilla/mux"
 )
 
 type UserController interface {
 	Register(w http.ResponseWriter, r *http.Request)
 	Login(w http.ResponseWriter, r *http.Request)
+	Refresh(w http.ResponseWriter, r *http.Request)
+	Logout(w http.ResponseWriter, r *http.Request)
+	VerifyEmail(w http.ResponseWriter, r *http.Request)
+	ResendVerificationEmail(w http.ResponseWriter, r *http.Request)
 	GetAllUsers(w http.ResponseWriter, r *http.Request)
+	SuspendUser(w http.ResponseWriter, r *http.Request)
+	UnsuspendUser(w http.ResponseWriter, r *http.Request)
+	BanUser(w http.ResponseWriter, r *http.Request)
+	UnbanUser(w http.ResponseWriter, r *http.Request)
+	ShadowBanUser(w http.ResponseWriter, r *http.Request)
+	UnshadowBanUser(w http.ResponseWriter, r *http.Request)
+	UpdateUserRole(w http.ResponseWriter, r *http.Request)
 }
 
 type UserControllerImpl struct {
 	UserService services.UserService
+	Proxies     *TrustedProxies
 }
 
-func NewUserController(userService services.UserService) UserController {
+func NewUserController(userService services.UserService, proxies *TrustedProxies) UserController {
 	return &UserControllerImpl{
 		UserService: userService,
+		Proxies:     proxies,
 	}
 }
 
@@ -33,6 +51,7 @@ func (controller *UserControllerImpl) Register(w http.ResponseWriter, r *http.Re
 		json.NewEncoder(w).Encode(resp)
 		return
 	}
+	sessionDevice(r, controller.Proxies, &req.SessionDevice)
 
 	_, err := controller.UserService.RegisterUser(r.Context(), &req)
 	if err != nil {
@@ -56,6 +75,7 @@ func (controller *UserControllerImpl) Login(w http.ResponseWriter, r *http.Reque
 		json.NewEncoder(w).Encode(resp)
 		return
 	}
+	sessionDevice(r, controller.Proxies, &req.SessionDevice)
 
 	user, err := controller.UserService.LoginUser(r.Context(), &req)
 	if err != nil {
@@ -70,15 +90,219 @@ func (controller *UserControllerImpl) Login(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(resp)
 }
 
+func (controller *UserControllerImpl) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req params.RefreshTokenRequest
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp := response.BadRequestError("Invalid input")
+		w.WriteHeader(resp.StatusCode)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	sessionDevice(r, controller.Proxies, &req.SessionDevice)
+
+	tokens, err := controller.UserService.RefreshToken(r.Context(), &req)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success refresh token", tokens)
+
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *UserControllerImpl) Logout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	err := controller.UserService.Logout(r.Context())
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success logout user", nil)
+
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *UserControllerImpl) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req params.VerifyEmailRequest
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp := response.BadRequestError("Invalid input")
+		w.WriteHeader(resp.StatusCode)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	result, err := controller.UserService.VerifyEmail(r.Context(), &req)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success verify email", result)
+
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *UserControllerImpl) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	err := controller.UserService.ResendVerificationEmail(r.Context())
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success resend verification email", nil)
+
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
 func (controller *UserControllerImpl) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	user, err := controller.UserService.GetAllUser(r.Context())
+
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	user, err := controller.UserService.SearchUsers(r.Context(), &params.UserSearchRequest{
+		Query: query.Get("q"),
+		Role:  query.Get("role"),
+		Page:  page,
+		Limit: limit,
+	})
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success get all data users", user)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *UserControllerImpl) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	controller.moderate(w, r, controller.UserService.SuspendUser, "Success suspend user")
+}
+
+func (controller *UserControllerImpl) UnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	controller.lift(w, r, controller.UserService.UnsuspendUser, "Success unsuspend user")
+}
+
+func (controller *UserControllerImpl) BanUser(w http.ResponseWriter, r *http.Request) {
+	controller.moderate(w, r, controller.UserService.BanUser, "Success ban user")
+}
+
+func (controller *UserControllerImpl) UnbanUser(w http.ResponseWriter, r *http.Request) {
+	controller.lift(w, r, controller.UserService.UnbanUser, "Success unban user")
+}
+
+func (controller *UserControllerImpl) ShadowBanUser(w http.ResponseWriter, r *http.Request) {
+	controller.moderate(w, r, controller.UserService.ShadowBanUser, "Success shadow-ban user")
+}
+
+func (controller *UserControllerImpl) UnshadowBanUser(w http.ResponseWriter, r *http.Request) {
+	controller.lift(w, r, controller.UserService.UnshadowBanUser, "Success lift shadow-ban")
+}
+
+// moderate reads a moderation request for the user in the route and hands it
+// to action.
+func (controller *UserControllerImpl) moderate(w http.ResponseWriter, r *http.Request, action func(context.Context, *params.ModerationRequest) (*params.GetAllUser, *response.CustomError), message string) {
+	w.Header().Set("Content-Type", "application/json")
+	actorID, ok := userIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req params.ModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp := response.BadRequestError("Invalid input")
+		w.WriteHeader(resp.StatusCode)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	vars := mux.Vars(r)
+	req.UserID, _ = strconv.ParseUint(vars["userID"], 10, 64)
+	req.ActorID = uint64(actorID)
+	req.ActorRole = middleware.RoleFromContext(r.Context())
+
+	result, err := action(r.Context(), &req)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload(message, result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *UserControllerImpl) lift(w http.ResponseWriter, r *http.Request, action func(context.Context, *params.LiftModerationRequest) (*params.GetAllUser, *response.CustomError), message string) {
+	w.Header().Set("Content-Type", "application/json")
+	actorID, ok := userIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req params.LiftModerationRequest
+	vars := mux.Vars(r)
+	req.UserID, _ = strconv.ParseUint(vars["userID"], 10, 64)
+	req.ActorID = uint64(actorID)
+	req.ActorRole = middleware.RoleFromContext(r.Context())
+
+	result, err := action(r.Context(), &req)
 	if err != nil {
 		w.WriteHeader(err.StatusCode)
 		json.NewEncoder(w).Encode(err)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response.GeneralSuccessCustomMessageAndPayload("Success get all data users", user))
+	resp := response.GeneralSuccessCustomMessageAndPayload(message, result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (controller *UserControllerImpl) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	actorID, ok := userIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req params.UpdateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp := response.BadRequestError("Invalid input")
+		w.WriteHeader(resp.StatusCode)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	vars := mux.Vars(r)
+	req.UserID, _ = strconv.ParseUint(vars["userID"], 10, 64)
+	req.ActorID = uint64(actorID)
+
+	result, err := controller.UserService.UpdateUserRole(r.Context(), &req)
+	if err != nil {
+		w.WriteHeader(err.StatusCode)
+		json.NewEncoder(w).Encode(err)
+		return
+	}
+
+	resp := response.GeneralSuccessCustomMessageAndPayload("Success update user role", result)
+	w.WriteHeader(resp.StatusCode)
+	json.NewEncoder(w).Encode(resp)
 }
@@ -0,0 +1,67 @@
+// Package export writes a conversation out in a downloadable format, one
+// message at a time so large chats never have to be held in memory.
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "txt"
+	FormatHTML = "html"
+)
+
+type Participant struct {
+	UserID uint64 `json:"user_id"`
+	Name   string `json:"name"`
+}
+
+type Conversation struct {
+	MatchID      uint64        `json:"match_id"`
+	ExportedAt   time.Time     `json:"exported_at"`
+	Participants []Participant `json:"participants"`
+}
+
+type Attachment struct {
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	URL         string `json:"url"`
+}
+
+type Message struct {
+	ID         uint64      `json:"id"`
+	SenderID   uint64      `json:"sender_id"`
+	SenderName string      `json:"sender_name"`
+	Type       string      `json:"type"`
+	Content    string      `json:"content"`
+	Attachment *Attachment `json:"attachment,omitempty"`
+	SentAt     time.Time   `json:"sent_at"`
+	EditedAt   *time.Time  `json:"edited_at,omitempty"`
+	Unsent     bool        `json:"unsent,omitempty"`
+}
+
+// Writer streams a conversation: Begin once, WriteMessage for every message
+// from oldest to newest, then End.
+type Writer interface {
+	ContentType() string
+	Begin(conversation *Conversation) error
+	WriteMessage(message *Message) error
+	End() error
+}
+
+// NewWriter returns the Writer for format, writing to w.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatJSON:
+		return &jsonWriter{w: w}, nil
+	case FormatText:
+		return &textWriter{w: w}, nil
+	case FormatHTML:
+		return &htmlWriter{w: w}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
@@ -0,0 +1,74 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"sweatsparks/internal/models"
+	"time"
+)
+
+type htmlWriter struct {
+	w io.Writer
+}
+
+func (h *htmlWriter) ContentType() string {
+	return "text/html; charset=utf-8"
+}
+
+func (h *htmlWriter) Begin(conversation *Conversation) error {
+	var names []string
+	for _, participant := range conversation.Participants {
+		names = append(names, html.EscapeString(participant.Name))
+	}
+	title := "Conversation between " + strings.Join(names, " and ")
+
+	_, err := fmt.Fprintf(h.w, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { font-family: sans-serif; max-width: 40em; margin: 2em auto; }
+.message { margin: 0.5em 0; }
+.meta { color: #777; font-size: 0.85em; }
+.system { color: #777; font-style: italic; text-align: center; }
+</style>
+</head>
+<body>
+<h1>%s</h1>
+<p class="meta">Exported %s</p>
+`, title, title, conversation.ExportedAt.Format(time.RFC1123))
+	return err
+}
+
+func (h *htmlWriter) WriteMessage(message *Message) error {
+	sentAt := message.SentAt.Format(textTimeLayout)
+	if message.Type == models.MessageTypeSystem {
+		_, err := fmt.Fprintf(h.w, "<p class=\"message system\">%s <span class=\"meta\">%s</span></p>\n",
+			html.EscapeString(message.Content), sentAt)
+		return err
+	}
+
+	body := html.EscapeString(message.Content)
+	switch {
+	case message.Unsent:
+		body = "<em>(message unsent)</em>"
+	case message.Attachment != nil:
+		body += fmt.Sprintf(` <a href="%s">%s</a>`,
+			html.EscapeString(message.Attachment.URL), html.EscapeString(message.Attachment.FileName))
+	}
+	if message.EditedAt != nil {
+		body += ` <span class="meta">(edited)</span>`
+	}
+
+	_, err := fmt.Fprintf(h.w, "<p class=\"message\"><span class=\"meta\">%s</span> <strong>%s</strong>: %s</p>\n",
+		sentAt, html.EscapeString(message.SenderName), body)
+	return err
+}
+
+func (h *htmlWriter) End() error {
+	_, err := io.WriteString(h.w, "</body>\n</html>\n")
+	return err
+}
@@ -0,0 +1,52 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// jsonWriter writes the conversation header fields followed by a messages
+// array it fills in as messages arrive.
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonWriter) ContentType() string {
+	return "application/json"
+}
+
+func (j *jsonWriter) Begin(conversation *Conversation) error {
+	header, err := json.Marshal(conversation)
+	if err != nil {
+		return err
+	}
+
+	// Reopen the marshalled object to append the messages array.
+	if _, err := j.w.Write(header[:len(header)-1]); err != nil {
+		return err
+	}
+	_, err = io.WriteString(j.w, `,"messages":[`)
+	return err
+}
+
+func (j *jsonWriter) WriteMessage(message *Message) error {
+	if j.count > 0 {
+		if _, err := io.WriteString(j.w, ","); err != nil {
+			return err
+		}
+	}
+	j.count++
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonWriter) End() error {
+	_, err := io.WriteString(j.w, "]}\n")
+	return err
+}
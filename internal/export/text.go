@@ -0,0 +1,57 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sweatsparks/internal/models"
+	"time"
+)
+
+const textTimeLayout = "2006-01-02 15:04"
+
+type textWriter struct {
+	w io.Writer
+}
+
+func (t *textWriter) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (t *textWriter) Begin(conversation *Conversation) error {
+	var names []string
+	for _, participant := range conversation.Participants {
+		names = append(names, participant.Name)
+	}
+
+	_, err := fmt.Fprintf(t.w, "Conversation between %s\nExported %s\n\n",
+		strings.Join(names, " and "), conversation.ExportedAt.Format(time.RFC1123))
+	return err
+}
+
+func (t *textWriter) WriteMessage(message *Message) error {
+	sentAt := message.SentAt.Format(textTimeLayout)
+	if message.Type == models.MessageTypeSystem {
+		_, err := fmt.Fprintf(t.w, "[%s] -- %s --\n", sentAt, message.Content)
+		return err
+	}
+
+	line := message.Content
+	switch {
+	case message.Unsent:
+		line = "(message unsent)"
+	case message.Attachment != nil:
+		link := fmt.Sprintf("[attachment: %s %s]", message.Attachment.FileName, message.Attachment.URL)
+		line = strings.TrimSpace(line + " " + link)
+	}
+	if message.EditedAt != nil {
+		line += " (edited)"
+	}
+
+	_, err := fmt.Fprintf(t.w, "[%s] %s: %s\n", sentAt, message.SenderName, line)
+	return err
+}
+
+func (t *textWriter) End() error {
+	return nil
+}
@@ -2,46 +2,125 @@ package factory
 
 import (
 	"database/sql"
+	"sweatsparks/internal/config"
 	"sweatsparks/internal/controllers"
+	"sweatsparks/internal/middleware"
 	"sweatsparks/internal/repositories"
+	"sweatsparks/internal/search"
 	"sweatsparks/internal/services"
+	websockets "sweatsparks/internal/websocket"
+	"sweatsparks/pkg/mailer"
+	"sweatsparks/pkg/presence"
+	"sweatsparks/pkg/storage"
+	"sweatsparks/pkg/token"
 )
 
 type Provider struct {
-	UserProvider    controllers.UserController
-	MatchProvider   controllers.MatchController
-	MessageProvider controllers.MessageController
-	ProfileProvider controllers.ProfileController
-	SwipeProvider   controllers.SwipeController
+	UserProvider       controllers.UserController
+	JWKSProvider       controllers.JWKSController
+	SessionProvider    controllers.SessionController
+	MatchProvider      controllers.MatchController
+	MessageProvider    controllers.MessageController
+	AttachmentProvider controllers.AttachmentController
+	ReactionProvider   controllers.ReactionController
+	ExportProvider     controllers.ExportController
+	BlockProvider      controllers.BlockController
+	ReportProvider     controllers.ReportController
+	APIKeyProvider     controllers.APIKeyController
+	ProfileProvider    controllers.ProfileController
+	SwipeProvider      controllers.SwipeController
+	PresenceProvider   controllers.PresenceController
+	WebsocketProvider  *websockets.Handler
+	TokenValidator     middleware.TokenValidator
+	APIKeyValidator    middleware.APIKeyValidator
+	Hub                *websockets.Hub
+	Presence           services.PresenceService
+	MessageExpiry      services.MessageExpiryService
 }
 
-func InitFactory(db *sql.DB) *Provider {
+func InitFactory(db *sql.DB, store storage.Storage, broker websockets.Broker, presenceStore presence.Store, searchIndex search.Index, tokens *token.Keyring, mail mailer.Mailer) *Provider {
+
+	emailPolicy := services.NewEmailPolicy(config.ENV.EmailVerifyRequired)
 
 	userRepo := repositories.NewUserRepository()
-	userService := services.NeewUserService(db, userRepo)
-	userController := controllers.NewUserController(userService)
+	sessionRepo := repositories.NewSessionRepository()
 
 	matchRepo := repositories.NewMatchRepository()
+	blockRepo := repositories.NewBlockRepository()
 	matchService := services.NewMatchService(db, matchRepo)
 	matchController := controllers.NewMatchController(matchService)
 
+	attachmentRepo := repositories.NewAttachmentRepository()
+	attachmentService := services.NewAttachmentService(db, attachmentRepo, matchRepo, store, config.ENV.AttachmentMaxBytes)
+	attachmentController := controllers.NewAttachmentController(attachmentService, config.ENV.AttachmentMaxBytes)
+
+	presenceService := services.NewPresenceService(db, userRepo, matchRepo, presenceStore)
+	presenceController := controllers.NewPresenceController(presenceService)
+
+	hub := websockets.NewHub(presenceService, broker)
+
+	userService := services.NeewUserService(db, userRepo, sessionRepo, hub, hub, searchIndex, tokens, mail, config.ENV.AccessTokenTTL, config.ENV.RefreshTokenTTL,
+		config.ENV.EmailVerifyURL, config.ENV.EmailVerifyTTL, config.ENV.EmailVerifyCooldown)
+	userController := controllers.NewUserController(userService, controllers.NewTrustedProxies(config.ENV.TrustedProxies))
+	jwksController := controllers.NewJWKSController(tokens)
+
+	sessionService := services.NewSessionService(db, sessionRepo, hub, config.ENV.RefreshTokenTTL)
+	sessionController := controllers.NewSessionController(sessionService)
+
 	messRepo := repositories.NewMessageRepository()
-	messService := services.NewMessageService(db, messRepo)
+	reactionRepo := repositories.NewReactionRepository()
+	messService := services.NewMessageService(db, messRepo, matchRepo, attachmentRepo, reactionRepo, userRepo, store, searchIndex, hub, emailPolicy, config.ENV.MessageUnsendWindow)
 	messController := controllers.NewMessageController(messService)
 
+	messExpiryService := services.NewMessageExpiryService(db, messRepo, matchRepo, attachmentRepo, store, searchIndex, hub)
+
+	reactionService := services.NewReactionService(db, reactionRepo, messRepo, matchRepo, userRepo, hub)
+	reactionController := controllers.NewReactionController(reactionService)
+
 	profRepo := repositories.NewProfileRepository()
-	profService := services.NewProfileService(db, profRepo)
+	profService := services.NewProfileService(db, profRepo, blockRepo, userRepo)
 	profController := controllers.NewProfileController(profService)
 
+	exportService := services.NewExportService(db, messRepo, matchRepo, attachmentRepo, profRepo)
+	exportController := controllers.NewExportController(exportService)
+
 	swipeRepo := repositories.NewSwipeRepository()
-	swipeService := services.NewSwipeService(db, swipeRepo)
+	swipeService := services.NewSwipeService(db, swipeRepo, matchRepo, blockRepo, userRepo, emailPolicy)
 	swipeController := controllers.NewSwipeController(swipeService)
 
+	blockService := services.NewBlockService(db, blockRepo, matchRepo, hub)
+	blockController := controllers.NewBlockController(blockService)
+
+	reportRepo := repositories.NewReportRepository()
+	reportService := services.NewReportService(db, reportRepo, profRepo, messRepo, matchRepo)
+	reportController := controllers.NewReportController(reportService)
+
+	apiKeyRepo := repositories.NewAPIKeyRepository()
+	apiKeyService := services.NewAPIKeyService(db, apiKeyRepo)
+	apiKeyController := controllers.NewAPIKeyController(apiKeyService)
+
+	wsHandler := websockets.NewHandler(hub, userService, matchService, messService, reactionService)
+
 	return &Provider{
-		UserProvider:    userController,
-		MatchProvider:   matchController,
-		MessageProvider: messController,
-		ProfileProvider: profController,
-		SwipeProvider:   swipeController,
+		UserProvider:       userController,
+		JWKSProvider:       jwksController,
+		SessionProvider:    sessionController,
+		MatchProvider:      matchController,
+		MessageProvider:    messController,
+		AttachmentProvider: attachmentController,
+		ReactionProvider:   reactionController,
+		ExportProvider:     exportController,
+		BlockProvider:      blockController,
+		ReportProvider:     reportController,
+		APIKeyProvider:     apiKeyController,
+		ProfileProvider:    profController,
+		SwipeProvider:      swipeController,
+		PresenceProvider:   presenceController,
+		WebsocketProvider:  wsHandler,
+		TokenValidator:     userService,
+		APIKeyValidator:    apiKeyService,
+		Hub:                hub,
+		Presence:           presenceService,
+		MessageExpiry:      messExpiryService,
 	}
 }
@@ -6,48 +6,185 @@ import (
 	"net/http"
 	"strings"
 	"sweatsparks/internal/commons/response"
-	"sweatsparks/pkg/token"
+	"sweatsparks/internal/models"
+	"sweatsparks/internal/params"
 )
 
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			resp := response.UnauthorizedError("Missing Authorization header")
-			w.WriteHeader(resp.StatusCode)
-			json.NewEncoder(w).Encode(resp)
-			return
-		}
+// TokenValidator turns a bearer token into the caller behind it. It returns
+// the error to send back when the token is invalid or the account is not
+// allowed in.
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, tokenStr string) (*params.AuthenticatedUser, *response.CustomError)
+}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			resp := response.UnauthorizedError("Invalid Authorization header format")
-			w.WriteHeader(resp.StatusCode)
-			json.NewEncoder(w).Encode(resp)
-			return
-		}
+// APIKeyValidator turns an X-API-Key header into the partner behind it, or
+// the error to send back, including when the key is over its rate limit.
+type APIKeyValidator interface {
+	ValidateAPIKey(ctx context.Context, apiKey string) (*params.AuthenticatedAPIKey, *response.CustomError)
+}
 
-		tokenStr := parts[1]
-		token, err := token.ValidateToken(tokenStr)
-		if err != nil {
-			resp := response.UnauthorizedError("Invalid token")
-			w.WriteHeader(resp.StatusCode)
-			json.NewEncoder(w).Encode(resp)
-			return
-		}
+// AuthMiddleware authenticates a request by its bearer token, or by its
+// X-API-Key header when keys is set. Routes served without keys are for users
+// only. A partner's request carries no user, only the key's scopes, which
+// RequireScope checks.
+func AuthMiddleware(validator TokenValidator, keys APIKeyValidator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			authHeader := r.Header.Get("Authorization")
+			if apiKey := r.Header.Get("X-API-Key"); authHeader == "" && apiKey != "" {
+				if keys == nil {
+					resp := response.ForbiddenError("API keys can not access this endpoint")
+					w.WriteHeader(resp.StatusCode)
+					json.NewEncoder(w).Encode(resp)
+					return
+				}
 
-		ctx := r.Context()
-		ctx = contextWithUserID(ctx, int64(token.AuthId))
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+				partner, resp := keys.ValidateAPIKey(r.Context(), apiKey)
+				if resp != nil {
+					w.WriteHeader(resp.StatusCode)
+					json.NewEncoder(w).Encode(resp)
+					return
+				}
+
+				next.ServeHTTP(w, r.WithContext(contextWithAPIKey(r.Context(), partner)))
+				return
+			}
+
+			if authHeader == "" {
+				resp := response.UnauthorizedError("Missing Authorization header")
+				w.WriteHeader(resp.StatusCode)
+				json.NewEncoder(w).Encode(resp)
+				return
+			}
+
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				resp := response.UnauthorizedError("Invalid Authorization header format")
+				w.WriteHeader(resp.StatusCode)
+				json.NewEncoder(w).Encode(resp)
+				return
+			}
+
+			user, resp := validator.ValidateToken(r.Context(), parts[1])
+			if resp != nil {
+				w.WriteHeader(resp.StatusCode)
+				json.NewEncoder(w).Encode(resp)
+				return
+			}
+
+			ctx := r.Context()
+			ctx = ContextWithUserID(ctx, int64(user.UserID))
+			ctx = contextWithSessionID(ctx, user.SessionID)
+			ctx = contextWithRole(ctx, user.Role)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
-func contextWithUserID(ctx context.Context, userID int64) context.Context {
-	return context.WithValue(ctx, "userID", userID)
+// contextKey keeps the values this package stores in a request context from
+// colliding with keys set by other packages.
+type contextKey string
+
+const (
+	userIDKey    contextKey = "userID"
+	sessionIDKey contextKey = "sessionID"
+	roleKey      contextKey = "role"
+	apiKeyKey    contextKey = "apiKey"
+)
+
+// ContextWithUserID marks ctx as acting for userID. AuthMiddleware does this
+// for requests; connections that outlive a request do it for each call they
+// make on the user's behalf.
+func ContextWithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
 }
 
 func UserIDFromContext(ctx context.Context) (int64, bool) {
-	userID, ok := ctx.Value("userID").(int64)
+	userID, ok := ctx.Value(userIDKey).(int64)
 	return userID, ok
 }
+
+func contextWithSessionID(ctx context.Context, sessionID uint64) context.Context {
+	return context.WithValue(ctx, sessionIDKey, sessionID)
+}
+
+// SessionIDFromContext returns the session of the access token a request came
+// with.
+func SessionIDFromContext(ctx context.Context) (uint64, bool) {
+	sessionID, ok := ctx.Value(sessionIDKey).(uint64)
+	return sessionID, ok
+}
+
+func contextWithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleKey, role)
+}
+
+// RoleFromContext returns the caller's role, counting a missing one as a
+// regular user.
+func RoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(roleKey).(string)
+	if role == "" {
+		return models.RoleUser
+	}
+	return role
+}
+
+func contextWithAPIKey(ctx context.Context, key *params.AuthenticatedAPIKey) context.Context {
+	return context.WithValue(ctx, apiKeyKey, key)
+}
+
+// APIKeyFromContext returns the partner key a request came with, if it came
+// with one rather than a user's token.
+func APIKeyFromContext(ctx context.Context) (*params.AuthenticatedAPIKey, bool) {
+	key, ok := ctx.Value(apiKeyKey).(*params.AuthenticatedAPIKey)
+	return key, ok
+}
+
+// RequireScope lets API keys through only when they were granted scope.
+// Users are not limited by scopes.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := APIKeyFromContext(r.Context())
+			if ok && !hasScope(key.Scopes, scope) {
+				w.Header().Set("Content-Type", "application/json")
+				resp := response.ForbiddenError("API key is missing the " + scope + " scope")
+				w.WriteHeader(resp.StatusCode)
+				json.NewEncoder(w).Encode(resp)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, granted := range scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole lets through only callers holding one of roles. It runs after
+// AuthMiddleware, which puts the caller's role in the context.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role := RoleFromContext(r.Context())
+			for _, allowed := range roles {
+				if role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			resp := response.ForbiddenError("Insufficient role")
+			w.WriteHeader(resp.StatusCode)
+			json.NewEncoder(w).Encode(resp)
+		})
+	}
+}
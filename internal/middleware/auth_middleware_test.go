@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/params"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stubTokens struct{}
+
+func (stubTokens) ValidateToken(ctx context.Context, tokenStr string) (*params.AuthenticatedUser, *response.CustomError) {
+	if tokenStr != "user-token" {
+		return nil, response.UnauthorizedError("Invalid token")
+	}
+	return &params.AuthenticatedUser{UserID: 7, SessionID: 1, Role: "user"}, nil
+}
+
+type stubKeys struct{}
+
+func (stubKeys) ValidateAPIKey(ctx context.Context, apiKey string) (*params.AuthenticatedAPIKey, *response.CustomError) {
+	if apiKey != "partner-key" {
+		return nil, response.UnauthorizedError("Invalid API key")
+	}
+	return &params.AuthenticatedAPIKey{KeyID: 1, Partner: "gym", Scopes: []string{"profiles:read"}}, nil
+}
+
+func serve(handler http.Handler, header, value string) int {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(header, value)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestAuthMiddlewareKeepsAPIKeysToScopedRoutes(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	userOnly := AuthMiddleware(stubTokens{}, nil)(ok)
+	require.Equal(t, http.StatusForbidden, serve(userOnly, "X-API-Key", "partner-key"))
+	require.Equal(t, http.StatusOK, serve(userOnly, "Authorization", "Bearer user-token"))
+
+	partner := AuthMiddleware(stubTokens{}, stubKeys{})
+	require.Equal(t, http.StatusOK, serve(partner(RequireScope("profiles:read")(ok)), "X-API-Key", "partner-key"))
+	require.Equal(t, http.StatusForbidden, serve(partner(RequireScope("matches:read")(ok)), "X-API-Key", "partner-key"))
+	require.Equal(t, http.StatusOK, serve(partner(RequireScope("matches:read")(ok)), "Authorization", "Bearer user-token"))
+	require.Equal(t, http.StatusUnauthorized, serve(partner(ok), "X-API-Key", "stolen-key"))
+}
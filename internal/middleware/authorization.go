@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"sweatsparks/internal/commons/response"
+)
+
+// ActingUserID returns the user ctx acts for, or the error to send back when
+// it acts for nobody.
+func ActingUserID(ctx context.Context) (uint64, *response.CustomError) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return 0, response.UnauthorizedError("Unauthorized")
+	}
+	return uint64(userID), nil
+}
+
+// AuthorizeUser checks that ctx acts for userID, the user a request claims
+// to act as or whose data it touches.
+func AuthorizeUser(ctx context.Context, userID uint64) *response.CustomError {
+	actorID, custErr := ActingUserID(ctx)
+	if custErr != nil {
+		return custErr
+	}
+	if actorID != userID {
+		return response.ForbiddenError("You can not act on behalf of another user.")
+	}
+	return nil
+}
+
+// AuthorizeClaimedUser resolves the user a request body says it acts as.
+// An unset claim means the caller; a claim naming anyone else is refused.
+func AuthorizeClaimedUser(ctx context.Context, claimedID uint64) (uint64, *response.CustomError) {
+	actorID, custErr := ActingUserID(ctx)
+	if custErr != nil {
+		return 0, custErr
+	}
+	if claimedID != 0 && claimedID != actorID {
+		return 0, response.ForbiddenError("You can not act on behalf of another user.")
+	}
+	return actorID, nil
+}
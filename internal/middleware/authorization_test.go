@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizeUser(t *testing.T) {
+	ctx := ContextWithUserID(context.Background(), 7)
+
+	require.Nil(t, AuthorizeUser(ctx, 7))
+
+	custErr := AuthorizeUser(ctx, 8)
+	require.NotNil(t, custErr)
+	require.Equal(t, http.StatusForbidden, custErr.StatusCode)
+
+	custErr = AuthorizeUser(context.Background(), 7)
+	require.NotNil(t, custErr)
+	require.Equal(t, http.StatusUnauthorized, custErr.StatusCode)
+}
+
+func TestAuthorizeClaimedUserDefaultsToCaller(t *testing.T) {
+	ctx := ContextWithUserID(context.Background(), 7)
+
+	userID, custErr := AuthorizeClaimedUser(ctx, 0)
+	require.Nil(t, custErr)
+	require.Equal(t, uint64(7), userID)
+
+	userID, custErr = AuthorizeClaimedUser(ctx, 7)
+	require.Nil(t, custErr)
+	require.Equal(t, uint64(7), userID)
+
+	_, custErr = AuthorizeClaimedUser(ctx, 8)
+	require.NotNil(t, custErr)
+	require.Equal(t, http.StatusForbidden, custErr.StatusCode)
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Scopes an API key can be granted. Each names the partner endpoints it opens.
+const (
+	ScopeProfilesRead = "profiles:read"
+)
+
+var APIKeyScopes = []string{ScopeProfilesRead}
+
+// APIKey lets a partner's server call the API without a user. Only the
+// SHA-256 of the key is stored; Prefix is kept in the clear so a key can be
+// recognised in listings. RateLimit is in requests per minute.
+type APIKey struct {
+	Id         uint64
+	Partner    string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	RateLimit  int
+	CreatedBy  uint64
+	CreatedAt  time.Time
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type Attachment struct {
+	Id          uint64
+	MatchID     uint64
+	UploaderID  uint64
+	StorageKey  string
+	FileName    string
+	ContentType string
+	Size        int64
+	CreatedAt   time.Time
+}
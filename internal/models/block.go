@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+type Block struct {
+	BlockerID uint64
+	BlockedID uint64
+	CreatedAt time.Time
+}
@@ -2,9 +2,24 @@ package models
 
 import "time"
 
+// Disappearing messages expire a while after they are read or after they are
+// sent, depending on the match's trigger.
+const (
+	DisappearOnRead = "read"
+	DisappearOnSent = "sent"
+)
+
 type Match struct {
 	Id          uint64
 	UserOne     uint64
 	UserTwo     uint64
 	MatchedTime time.Time
+	// DisappearAfter is in seconds; 0 turns disappearing messages off.
+	DisappearAfter   int64
+	DisappearTrigger string
+}
+
+// HasMember reports whether userID is one of the two sides of the match.
+func (match *Match) HasMember(userID uint64) bool {
+	return match.UserOne == userID || match.UserTwo == userID
 }
@@ -1,11 +1,40 @@
 package models
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
+
+// System messages are written by the server, such as a note that a setting of
+// the conversation changed. SenderID is the user who caused it.
+const (
+	MessageTypeText   = "text"
+	MessageTypeSystem = "system"
+)
 
 type Message struct {
-	Id       uint64
-	MatchID  uint64
-	SenderID uint64
-	Content  string
-	SendAt   time.Time
+	Id              uint64
+	MatchID         uint64
+	SenderID        uint64
+	Type            string
+	ClientMessageID sql.NullString
+	Content         string
+	AttachmentID    sql.NullInt64
+	SendAt          time.Time
+	DeliveredAt     sql.NullTime
+	ReadAt          sql.NullTime
+	EditedAt        sql.NullTime
+	DeletedAt       sql.NullTime
+	// ExpireAfter is set, in seconds, on messages that start expiring once
+	// they are read. ExpiresAt is when the sweeper may delete the message.
+	ExpireAfter sql.NullInt64
+	ExpiresAt   sql.NullTime
+}
+
+// MessageEdit keeps the content a message had before an edit replaced it.
+type MessageEdit struct {
+	Id        uint64
+	MessageID uint64
+	Content   string
+	EditedAt  time.Time
 }
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Reaction is a user's emoji on a message. A user has at most one reaction per
+// message; reacting again replaces it.
+type Reaction struct {
+	MessageID uint64
+	UserID    uint64
+	Emoji     string
+	CreatedAt time.Time
+}
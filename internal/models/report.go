@@ -0,0 +1,38 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+const (
+	ReportTargetProfile = "profile"
+	ReportTargetPhoto   = "photo"
+	ReportTargetMessage = "message"
+)
+
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusActioned  = "actioned"
+	ReportStatusDismissed = "dismissed"
+)
+
+// Report is a user's complaint about a profile, photo or message. Snapshot
+// holds the reported content as it was when the report was made, so it can
+// be reviewed even after the content is edited or removed.
+type Report struct {
+	Id             uint64
+	ReporterID     uint64
+	ReportedUserID uint64
+	TargetType     string
+	TargetID       uint64
+	Reason         string
+	Details        string
+	Snapshot       json.RawMessage
+	Status         string
+	ReviewerID     sql.NullInt64
+	ReviewNote     sql.NullString
+	ReviewedAt     sql.NullTime
+	CreatedAt      time.Time
+}
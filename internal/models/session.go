@@ -0,0 +1,32 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Session is one login. Every refresh token issued for it belongs to the same
+// family, and revoking the session ends all of them along with the access
+// tokens that carry its ID.
+type Session struct {
+	Id          uint64
+	UserID      uint64
+	DeviceLabel string
+	UserAgent   string
+	IPAddress   string
+	CreatedAt   time.Time
+	LastUsedAt  time.Time
+	RevokedAt   sql.NullTime
+}
+
+// RefreshToken is stored by the SHA-256 of its value only. A token is used
+// once: refreshing marks it used and issues a child in the same session.
+type RefreshToken struct {
+	Id        uint64
+	SessionID uint64
+	ParentID  sql.NullInt64
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
@@ -2,6 +2,11 @@ package models
 
 import "time"
 
+const (
+	SwipeDirectionLeft  = "left"
+	SwipeDirectionRight = "right"
+)
+
 type Swipe struct {
 	Id        uint64
 	SwiperID  uint64
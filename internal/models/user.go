@@ -1,12 +1,61 @@
 package models
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
+
+// Roles a user can hold. Moderators review reports and suspend accounts;
+// admins can also change roles.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
 
 type User struct {
-	Id           uint64
-	Username     string
-	Email        string
-	PasswordHash string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	Id               uint64
+	Username         string
+	Email            string
+	PasswordHash     string
+	Role             string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	LastSeenAt       sql.NullTime
+	SuspendedAt      sql.NullTime
+	SuspendedUntil   sql.NullTime
+	SuspensionReason sql.NullString
+	BannedAt         sql.NullTime
+	BanReason        sql.NullString
+	// ShadowBannedAt is set on accounts whose swipes and messages are kept
+	// but never shown to anyone else. The user is not told.
+	ShadowBannedAt sql.NullTime
+	// EmailVerifiedAt is set once the user follows the link mailed to Email.
+	// EmailVerificationSentAt is when that link was last sent.
+	EmailVerifiedAt         sql.NullTime
+	EmailVerificationSentAt sql.NullTime
+}
+
+// IsSuspended reports whether a suspension is in force at now. A suspension
+// without an end lasts until it is lifted.
+func (user *User) IsSuspended(now time.Time) bool {
+	return user.SuspendedAt.Valid && (!user.SuspendedUntil.Valid || now.Before(user.SuspendedUntil.Time))
+}
+
+func (user *User) IsEmailVerified() bool {
+	return user.EmailVerifiedAt.Valid
+}
+
+func (user *User) IsBanned() bool {
+	return user.BannedAt.Valid
+}
+
+func (user *User) IsShadowBanned() bool {
+	return user.ShadowBannedAt.Valid
+}
+
+// IsHidden reports whether the account is kept out of other users' sight:
+// suspended, banned or shadow-banned.
+func (user *User) IsHidden(now time.Time) bool {
+	return user.IsSuspended(now) || user.IsBanned() || user.IsShadowBanned()
 }
@@ -0,0 +1,10 @@
+package params
+
+// APIKeyRequest issues a key to a partner. RateLimit is in requests per
+// minute.
+type APIKeyRequest struct {
+	CreatedBy uint64   `validate:"required"`
+	Partner   string   `json:"partner" validate:"required,max=100"`
+	Scopes    []string `json:"scopes" validate:"required,min=1,dive,oneof=profiles:read"`
+	RateLimit int      `json:"rate_limit" validate:"required,min=1,max=100000"`
+}
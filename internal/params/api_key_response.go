@@ -0,0 +1,28 @@
+package params
+
+import "time"
+
+type APIKeyResponse struct {
+	ID         uint64     `json:"id"`
+	Partner    string     `json:"partner"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	RateLimit  int        `json:"rate_limit"`
+	CreatedBy  uint64     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// APIKeyCreatedResponse is the only response that carries the key itself.
+type APIKeyCreatedResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// AuthenticatedAPIKey is the partner behind a valid API key.
+type AuthenticatedAPIKey struct {
+	KeyID   uint64
+	Partner string
+	Scopes  []string
+}
@@ -0,0 +1,11 @@
+package params
+
+import "io"
+
+type AttachmentUploadRequest struct {
+	MatchID    uint64 `validate:"required"`
+	UploaderID uint64 `validate:"required"`
+	FileName   string `validate:"required,max=255"`
+	Size       int64  `validate:"required"`
+	File       io.Reader
+}
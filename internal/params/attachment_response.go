@@ -0,0 +1,24 @@
+package params
+
+import (
+	"io"
+	"time"
+)
+
+type AttachmentResponse struct {
+	Id          uint64    `json:"id"`
+	MatchID     uint64    `json:"match_id"`
+	Kind        string    `json:"kind"`
+	ContentType string    `json:"content_type"`
+	FileName    string    `json:"file_name"`
+	Size        int64     `json:"size"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AttachmentDownloadResponse carries an open handle on the stored file. The
+// caller is responsible for closing Content.
+type AttachmentDownloadResponse struct {
+	Attachment *AttachmentResponse
+	Content    io.ReadCloser
+}
@@ -0,0 +1,6 @@
+package params
+
+type BlockRequest struct {
+	BlockerID uint64 `validate:"required"`
+	BlockedID uint64 `validate:"required,nefield=BlockerID"`
+}
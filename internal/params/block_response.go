@@ -0,0 +1,12 @@
+package params
+
+import "time"
+
+type BlockResponse struct {
+	UserID    uint64    `json:"user_id"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+type MatchClosedResponse struct {
+	MatchID uint64 `json:"match_id"`
+}
@@ -1,6 +1,7 @@
 package params
 
-type MatchRequest struct {
-	UserOne int `json:"user_one" validate:"required"`
-	UserTwo int `json:"user_two" validate:"required"`
+type ConversationExportRequest struct {
+	MatchID uint64 `validate:"required"`
+	UserID  uint64 `validate:"required"`
+	Format  string `validate:"required,oneof=json txt html"`
 }
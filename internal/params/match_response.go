@@ -7,4 +7,7 @@ type MatchDetailResponse struct {
 	UserOne     uint64    `json:"user_one"`
 	UserTwo     uint64    `json:"user_two"`
 	MatchedTime time.Time `json:"matched_at"`
+	// DisappearAfter is off, 1h, 24h or 7d.
+	DisappearAfter   string `json:"disappear_after"`
+	DisappearTrigger string `json:"disappear_trigger"`
 }
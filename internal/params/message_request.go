@@ -0,0 +1,70 @@
+package params
+
+type MessageRequest struct {
+	MatchID         uint64 `validate:"required"`
+	SenderID        uint64 `validate:"required"`
+	ClientMessageID string `json:"client_message_id" validate:"required,max=64"`
+	Content         string `json:"content" validate:"required_without=AttachmentID,max=4000"`
+	AttachmentID    uint64 `json:"attachment_id"`
+}
+
+// MessageReceiptRequest marks every message in the match sent by the other
+// participant, up to and including MessageID, as delivered to or read by UserID.
+type MessageReceiptRequest struct {
+	MatchID   uint64 `validate:"required"`
+	UserID    uint64 `validate:"required"`
+	MessageID uint64 `json:"message_id" validate:"required"`
+}
+
+// MessageHistoryRequest pages through a conversation. Before and After are
+// message IDs and are mutually exclusive. Forward pages towards newer messages
+// even when After is 0, starting from the first message.
+type MessageHistoryRequest struct {
+	MatchID uint64 `validate:"required"`
+	UserID  uint64 `validate:"required"`
+	Before  uint64
+	After   uint64
+	Forward bool
+	Limit   int `validate:"min=0,max=100"`
+}
+
+type MessageEditRequest struct {
+	MatchID   uint64 `validate:"required"`
+	MessageID uint64 `validate:"required"`
+	UserID    uint64 `validate:"required"`
+	Content   string `json:"content" validate:"required,max=4000"`
+}
+
+// MessageDeleteRequest identifies a message to unsend for everyone or to
+// delete for UserID only.
+type MessageDeleteRequest struct {
+	MatchID   uint64 `validate:"required"`
+	MessageID uint64 `validate:"required"`
+	UserID    uint64 `validate:"required"`
+}
+
+// ReactionRequest adds Emoji as UserID's reaction to a message. Emoji is
+// ignored when the reaction is removed.
+type ReactionRequest struct {
+	MatchID   uint64 `validate:"required"`
+	MessageID uint64 `validate:"required"`
+	UserID    uint64 `validate:"required"`
+	Emoji     string `json:"emoji"`
+}
+
+// DisappearingRequest changes how long new messages in a match live. Trigger
+// says whether the clock starts when a message is read or when it is sent.
+type DisappearingRequest struct {
+	MatchID  uint64 `validate:"required"`
+	UserID   uint64 `validate:"required"`
+	Duration string `json:"duration" validate:"required,oneof=off 1h 24h 7d"`
+	Trigger  string `json:"trigger" validate:"omitempty,oneof=read sent"`
+}
+
+// MessageSearchRequest searches UserID's conversations. Page starts at 1.
+type MessageSearchRequest struct {
+	UserID uint64 `validate:"required"`
+	Query  string `validate:"required,max=200"`
+	Page   int    `validate:"min=0"`
+	Limit  int    `validate:"min=0,max=50"`
+}
@@ -3,9 +3,102 @@ package params
 import "time"
 
 type MessageResponse struct {
+	Id              uint64              `json:"id"`
+	MatchID         uint64              `json:"match_id"`
+	SenderID        uint64              `json:"sender_id"`
+	Type            string              `json:"type"`
+	ClientMessageID string              `json:"client_message_id,omitempty"`
+	Content         string              `json:"content"`
+	Attachment      *AttachmentResponse `json:"attachment,omitempty"`
+	SendAt          time.Time           `json:"sent_at"`
+	DeliveredAt     *time.Time          `json:"delivered_at"`
+	ReadAt          *time.Time          `json:"read_at"`
+	EditedAt        *time.Time          `json:"edited_at"`
+	DeletedAt       *time.Time          `json:"deleted_at"`
+	ExpiresAt       *time.Time          `json:"expires_at"`
+	Reactions       []*ReactionGroup    `json:"reactions"`
+}
+
+type MessageEditResponse struct {
 	Id       uint64    `json:"id"`
-	MatchID  uint64    `json:"match_id"`
-	SenderID uint64    `json:"sender_id"`
 	Content  string    `json:"content"`
-	SendAt   time.Time `json:"sent_at"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+type MessageDeletedResponse struct {
+	MessageID uint64 `json:"message_id"`
+	MatchID   uint64 `json:"match_id"`
+}
+
+// MessageSendResponse is returned to the realtime layer after a send. A
+// Duplicate result means the client retried a message that was already
+// stored, so it should be acked again but not fanned out a second time.
+type MessageSendResponse struct {
+	Message    *MessageResponse
+	Recipients []uint64
+	Duplicate  bool
+}
+
+type MessageReceiptResponse struct {
+	MatchID    uint64    `json:"match_id"`
+	UserID     uint64    `json:"user_id"`
+	MessageID  uint64    `json:"message_id"`
+	At         time.Time `json:"at"`
+	Updated    int64     `json:"-"`
+	Recipients []uint64  `json:"-"`
+}
+
+type ConversationResponse struct {
+	MatchID     uint64             `json:"match_id"`
+	UnreadCount int                `json:"unread_count"`
+	Messages    []*MessageResponse `json:"messages"`
+	HasMore     bool               `json:"has_more"`
+	NextCursor  *uint64            `json:"next_cursor"`
+}
+
+// ReactionGroup is every reaction with the same emoji on one message.
+type ReactionGroup struct {
+	Emoji   string   `json:"emoji"`
+	Count   int      `json:"count"`
+	UserIDs []uint64 `json:"user_ids"`
+}
+
+type ReactionResponse struct {
+	MessageID uint64    `json:"message_id"`
+	MatchID   uint64    `json:"match_id"`
+	UserID    uint64    `json:"user_id"`
+	Emoji     string    `json:"emoji"`
+	At        time.Time `json:"at"`
+}
+
+type MessageExpiredResponse struct {
+	MatchID    uint64   `json:"match_id"`
+	MessageIDs []uint64 `json:"message_ids"`
+}
+
+// DisappearingResponse carries the match's new setting and the system message
+// announcing it, which is nil when nothing changed.
+type DisappearingResponse struct {
+	MatchID  uint64           `json:"match_id"`
+	Duration string           `json:"duration"`
+	Trigger  string           `json:"trigger"`
+	Message  *MessageResponse `json:"message"`
+}
+
+// MessageSearchResult is one hit. Snippet is HTML escaped, with the matched
+// words wrapped in <mark>.
+type MessageSearchResult struct {
+	MessageID uint64    `json:"message_id"`
+	MatchID   uint64    `json:"match_id"`
+	SenderID  uint64    `json:"sender_id"`
+	Snippet   string    `json:"snippet"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+type MessageSearchResponse struct {
+	Query   string                 `json:"query"`
+	Results []*MessageSearchResult `json:"results"`
+	Page    int                    `json:"page"`
+	Limit   int                    `json:"limit"`
+	HasMore bool                   `json:"has_more"`
 }
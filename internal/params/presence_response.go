@@ -0,0 +1,9 @@
+package params
+
+import "time"
+
+type PresenceResponse struct {
+	UserID     uint64     `json:"user_id"`
+	Online     bool       `json:"online"`
+	LastSeenAt *time.Time `json:"last_seen_at"`
+}
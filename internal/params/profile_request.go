@@ -11,7 +11,7 @@ type PhotoRequest struct {
 }
 
 type ProfileRequest struct {
-	UserID           uint64
+	UserID           uint64          `json:"user_id"`
 	FirstName        string          `json:"first_name" validate:"required"`
 	LastName         string          `json:"last_name" validate:"required"`
 	Gender           string          `json:"gender" validate:"required"`
@@ -22,3 +22,19 @@ type ProfileResponse struct {
 	Interest         json.RawMessage  `json:"interest"`
 	Photo            []*PhotoResponse `json:"photo"`
 }
+
+// AdminProfileResponse is a profile as moderators see it, including profiles
+// hidden from other users and the state of the account behind it.
+type AdminProfileResponse struct {
+	ProfileResponse
+	Hidden bool                  `json:"hidden"`
+	User   *GetAllUser           `json:"user"`
+	Photos []*AdminPhotoResponse `json:"photos"`
+}
+
+type AdminPhotoResponse struct {
+	Id         uint64    `json:"id"`
+	URL        string    `json:"url"`
+	IsPrimary  int8      `json:"is_primary"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
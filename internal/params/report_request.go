@@ -0,0 +1,22 @@
+package params
+
+type ReportRequest struct {
+	ReporterID uint64 `validate:"required"`
+	TargetType string `json:"target_type" validate:"required,oneof=profile photo message"`
+	TargetID   uint64 `json:"target_id" validate:"required"`
+	Reason     string `json:"reason" validate:"required,oneof=spam harassment inappropriate fake_profile underage other"`
+	Details    string `json:"details" validate:"max=2000"`
+}
+
+type ReportListRequest struct {
+	Status string `validate:"required,oneof=open actioned dismissed"`
+	Page   int    `validate:"min=0"`
+	Limit  int    `validate:"min=0,max=100"`
+}
+
+type ReportReviewRequest struct {
+	ReportID   uint64 `validate:"required"`
+	ReviewerID uint64 `validate:"required"`
+	Status     string `json:"status" validate:"required,oneof=actioned dismissed"`
+	Note       string `json:"note" validate:"max=2000"`
+}
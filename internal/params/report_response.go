@@ -0,0 +1,32 @@
+package params
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type ReportResponse struct {
+	Id             uint64          `json:"id"`
+	ReporterID     uint64          `json:"reporter_id"`
+	ReportedUserID uint64          `json:"reported_user_id"`
+	TargetType     string          `json:"target_type"`
+	TargetID       uint64          `json:"target_id"`
+	Reason         string          `json:"reason"`
+	Details        string          `json:"details"`
+	Snapshot       json.RawMessage `json:"snapshot"`
+	Status         string          `json:"status"`
+	ReviewerID     *uint64         `json:"reviewer_id,omitempty"`
+	ReviewNote     *string         `json:"review_note,omitempty"`
+	ReviewedAt     *time.Time      `json:"reviewed_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// ReportSubmittedResponse is what the reporter gets back. The snapshot and
+// the moderation state stay with the moderators.
+type ReportSubmittedResponse struct {
+	Id         uint64    `json:"id"`
+	TargetType string    `json:"target_type"`
+	TargetID   uint64    `json:"target_id"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
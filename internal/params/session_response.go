@@ -0,0 +1,19 @@
+package params
+
+import "time"
+
+// SessionResponse is one signed-in device. Current marks the session of the
+// access token that asked.
+type SessionResponse struct {
+	ID          uint64    `json:"id"`
+	DeviceLabel string    `json:"device_label"`
+	UserAgent   string    `json:"user_agent"`
+	IPAddress   string    `json:"ip_address"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+	Current     bool      `json:"current"`
+}
+
+type SessionsRevokedResponse struct {
+	Revoked int `json:"revoked"`
+}
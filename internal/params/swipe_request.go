@@ -1,7 +1,9 @@
 package params
 
+// SwipeRequest is made by the caller. SwiperID may be left out; when it is
+// set it has to be the caller.
 type SwipeRequest struct {
-	SwiperID  uint64 `json:"swiper_id"`
-	SwipeeID  uint64 `json:"swipee_id"`
-	Direction string `json:"direction"`
+	SwiperID  uint64 `json:"swiper_id" validate:"required"`
+	SwipeeID  uint64 `json:"swipee_id" validate:"required"`
+	Direction string `json:"direction" validate:"required,oneof=left right"`
 }
@@ -8,4 +8,6 @@ type SwipeResponse struct {
 	SwipeeID  uint64    `json:"swipee_id"`
 	Direction string    `json:"direction"`
 	SwipedAt  time.Time `json:"swiped_at"`
+	Matched   bool      `json:"matched"`
+	MatchID   uint64    `json:"match_id,omitempty"`
 }
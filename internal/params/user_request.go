@@ -1,12 +1,68 @@
 package params
 
+import "time"
+
 type UserRegisterRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
+	SessionDevice
 }
 
 type UserLoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	SessionDevice
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+	SessionDevice
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// SessionDevice says where a session was started or last refreshed from.
+// DeviceLabel is the name the client gives itself; the controller fills in
+// the rest from the request.
+type SessionDevice struct {
+	DeviceLabel string `json:"device_label" validate:"max=100"`
+	UserAgent   string `json:"-"`
+	IPAddress   string `json:"-"`
+}
+
+// UserSearchRequest filters the admin user list. Query matches username or
+// email. Page starts at 1.
+type UserSearchRequest struct {
+	Query string
+	Role  string `validate:"omitempty,oneof=user moderator admin"`
+	Page  int    `validate:"min=0"`
+	Limit int    `validate:"min=0,max=100"`
+}
+
+// ModerationRequest suspends, bans or shadow-bans UserID. ActorRole is the
+// moderator's own role, which decides whose accounts they may act on. Until
+// is only read for suspensions.
+type ModerationRequest struct {
+	UserID    uint64     `validate:"required"`
+	ActorID   uint64     `validate:"required,nefield=UserID"`
+	ActorRole string     `validate:"required"`
+	Reason    string     `json:"reason" validate:"max=255"`
+	Until     *time.Time `json:"until"`
+}
+
+// LiftModerationRequest lifts a suspension, ban or shadow-ban from UserID,
+// with the same rules on ActorRole as ModerationRequest.
+type LiftModerationRequest struct {
+	UserID    uint64 `validate:"required"`
+	ActorID   uint64 `validate:"required,nefield=UserID"`
+	ActorRole string `validate:"required"`
+}
+
+type UpdateRoleRequest struct {
+	UserID  uint64 `validate:"required"`
+	ActorID uint64 `validate:"required,nefield=UserID"`
+	Role    string `json:"role" validate:"required,oneof=user moderator admin"`
 }
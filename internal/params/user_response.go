@@ -3,18 +3,49 @@ package params
 import "time"
 
 type UserRegisterResponse struct {
-	Email    string `json:"email"`
-	Username string `json:"username"`
-	Token    string `json:"token"`
+	Email         string `json:"email"`
+	Username      string `json:"username"`
+	Token         string `json:"token"`
+	RefreshToken  string `json:"refresh_token"`
+	EmailVerified bool   `json:"email_verified"`
 }
 
+// UserLoginResponse carries a short-lived access token and the refresh token
+// that replaces it. Each refresh returns a new pair and spends the old
+// refresh token.
 type UserLoginResponse struct {
-	Token string `json:"token"`
+	Token          string    `json:"token"`
+	TokenExpiresAt time.Time `json:"token_expires_at"`
+	RefreshToken   string    `json:"refresh_token"`
 }
 
 type GetAllUser struct {
-	ID        uint64    `json:"user_id"`
-	Username  string    `json:"username"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID               uint64     `json:"user_id"`
+	Username         string     `json:"username"`
+	Email            string     `json:"email"`
+	EmailVerifiedAt  *time.Time `json:"email_verified_at,omitempty"`
+	Role             string     `json:"role"`
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	SuspendedUntil   *time.Time `json:"suspended_until,omitempty"`
+	SuspensionReason string     `json:"suspension_reason,omitempty"`
+	BannedAt         *time.Time `json:"banned_at,omitempty"`
+	BanReason        string     `json:"ban_reason,omitempty"`
+	ShadowBannedAt   *time.Time `json:"shadow_banned_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+type EmailVerificationResponse struct {
+	UserID          uint64    `json:"user_id"`
+	Email           string    `json:"email"`
+	EmailVerifiedAt time.Time `json:"email_verified_at"`
+}
+
+// AuthenticatedUser is the caller behind a valid token, as the account stands
+// now rather than when the token was issued.
+type AuthenticatedUser struct {
+	UserID       uint64
+	SessionID    uint64
+	Role         string
+	ShadowBanned bool
 }
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sweatsparks/internal/models"
+	"time"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, tx *sql.Tx, key *models.APIKey) error
+	FindAPIKeyByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.APIKey, error)
+	FindAPIKeyByHash(ctx context.Context, tx *sql.Tx, keyHash string) (*models.APIKey, error)
+	FindAPIKeys(ctx context.Context, tx *sql.Tx, partner string) ([]*models.APIKey, error)
+	TouchAPIKey(ctx context.Context, tx *sql.Tx, id uint64, usedAt time.Time) error
+	RevokeAPIKey(ctx context.Context, tx *sql.Tx, id uint64, revokedAt time.Time) error
+}
+
+type APIKeyRepositoryImpl struct{}
+
+func NewAPIKeyRepository() APIKeyRepository {
+	return &APIKeyRepositoryImpl{}
+}
+
+const apiKeyColumns = `id, partner, key_prefix, key_hash, scopes, rate_limit, created_by, created_at, last_used_at, revoked_at`
+
+func scanAPIKey(rows *sql.Rows) (*models.APIKey, error) {
+	var key models.APIKey
+	var scopes string
+	err := rows.Scan(&key.Id, &key.Partner, &key.Prefix, &key.KeyHash, &scopes, &key.RateLimit, &key.CreatedBy, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	if scopes != "" {
+		key.Scopes = strings.Split(scopes, ",")
+	}
+	return &key, nil
+}
+
+func (repository *APIKeyRepositoryImpl) CreateAPIKey(ctx context.Context, tx *sql.Tx, key *models.APIKey) error {
+	SQL := `INSERT INTO api_keys (partner, key_prefix, key_hash, scopes, rate_limit, created_by, created_at) VALUES (?,?,?,?,?,?,?)`
+	response, err := tx.ExecContext(ctx, SQL, key.Partner, key.Prefix, key.KeyHash, strings.Join(key.Scopes, ","), key.RateLimit, key.CreatedBy, key.CreatedAt)
+	if err != nil {
+		return errors.New("Failed to create an api key, transaction rolled back. Reason: " + err.Error())
+	}
+	keyID, err := response.LastInsertId()
+	if err != nil {
+		return errors.New("Failed to retrieve api_key_id, transaction rolled back. Reason:" + err.Error())
+	}
+
+	key.Id = uint64(keyID)
+	return nil
+}
+
+func (repository *APIKeyRepositoryImpl) FindAPIKeyByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.APIKey, error) {
+	SQL := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = ?`
+	rows, err := tx.QueryContext(ctx, SQL, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanAPIKey(rows)
+	} else {
+		return nil, errors.New("api key is not found")
+	}
+}
+
+func (repository *APIKeyRepositoryImpl) FindAPIKeyByHash(ctx context.Context, tx *sql.Tx, keyHash string) (*models.APIKey, error) {
+	SQL := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = ?`
+	rows, err := tx.QueryContext(ctx, SQL, keyHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanAPIKey(rows)
+	} else {
+		return nil, errors.New("api key is not found")
+	}
+}
+
+// FindAPIKeys lists keys newest first, revoked ones included. An empty
+// partner lists every partner's keys.
+func (repository *APIKeyRepositoryImpl) FindAPIKeys(ctx context.Context, tx *sql.Tx, partner string) ([]*models.APIKey, error) {
+	SQL := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE (? = '' OR partner = ?) ORDER BY id DESC`
+	rows, err := tx.QueryContext(ctx, SQL, partner, partner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (repository *APIKeyRepositoryImpl) TouchAPIKey(ctx context.Context, tx *sql.Tx, id uint64, usedAt time.Time) error {
+	SQL := `UPDATE api_keys SET last_used_at = ? WHERE id = ?`
+	_, err := tx.ExecContext(ctx, SQL, usedAt, id)
+	if err != nil {
+		return errors.New("Failed to update an api key, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *APIKeyRepositoryImpl) RevokeAPIKey(ctx context.Context, tx *sql.Tx, id uint64, revokedAt time.Time) error {
+	SQL := `UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`
+	_, err := tx.ExecContext(ctx, SQL, revokedAt, id)
+	if err != nil {
+		return errors.New("Failed to revoke an api key, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sweatsparks/internal/models"
+)
+
+type AttachmentRepository interface {
+	CreateAttachment(ctx context.Context, tx *sql.Tx, attachment *models.Attachment) error
+	FindAttachmentByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.Attachment, error)
+	FindAttachmentsByIDs(ctx context.Context, tx *sql.Tx, ids []uint64) ([]*models.Attachment, error)
+	IsAttachmentLinked(ctx context.Context, tx *sql.Tx, id uint64) (bool, error)
+	DeleteAttachment(ctx context.Context, tx *sql.Tx, id uint64) error
+}
+
+type AttachmentRepositoryImpl struct{}
+
+func NewAttachmentRepository() AttachmentRepository {
+	return &AttachmentRepositoryImpl{}
+}
+
+const attachmentColumns = `id, match_id, uploader_id, storage_key, file_name, content_type, size_bytes, created_at`
+
+func scanAttachment(rows *sql.Rows) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := rows.Scan(
+		&attachment.Id,
+		&attachment.MatchID,
+		&attachment.UploaderID,
+		&attachment.StorageKey,
+		&attachment.FileName,
+		&attachment.ContentType,
+		&attachment.Size,
+		&attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (repository *AttachmentRepositoryImpl) CreateAttachment(ctx context.Context, tx *sql.Tx, attachment *models.Attachment) error {
+	SQL := `INSERT INTO attachments (match_id, uploader_id, storage_key, file_name, content_type, size_bytes, created_at) VALUES (?,?,?,?,?,?,?)`
+	response, err := tx.ExecContext(ctx, SQL,
+		attachment.MatchID,
+		attachment.UploaderID,
+		attachment.StorageKey,
+		attachment.FileName,
+		attachment.ContentType,
+		attachment.Size,
+		attachment.CreatedAt,
+	)
+	if err != nil {
+		return errors.New("Failed to create an attachment, transaction rolled back. Reason: " + err.Error())
+	}
+	attachmentID, err := response.LastInsertId()
+	if err != nil {
+		return errors.New("Failed to retrieve attachment_id, transaction rolled back. Reason:" + err.Error())
+	}
+
+	attachment.Id = uint64(attachmentID)
+	return nil
+}
+
+func (repository *AttachmentRepositoryImpl) FindAttachmentByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.Attachment, error) {
+	SQL := `SELECT ` + attachmentColumns + ` FROM attachments WHERE id = ?`
+	rows, err := tx.QueryContext(ctx, SQL, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanAttachment(rows)
+	} else {
+		return nil, errors.New("attachment is not found")
+	}
+}
+
+func (repository *AttachmentRepositoryImpl) FindAttachmentsByIDs(ctx context.Context, tx *sql.Tx, ids []uint64) ([]*models.Attachment, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	SQL := `SELECT ` + attachmentColumns + ` FROM attachments WHERE id IN (?` + strings.Repeat(",?", len(ids)-1) + `)`
+	rows, err := tx.QueryContext(ctx, SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []*models.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, rows.Err()
+}
+
+func (repository *AttachmentRepositoryImpl) IsAttachmentLinked(ctx context.Context, tx *sql.Tx, id uint64) (bool, error) {
+	SQL := `SELECT COUNT(*) FROM messages WHERE attachment_id = ?`
+	var count int
+	if err := tx.QueryRowContext(ctx, SQL, id).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (repository *AttachmentRepositoryImpl) DeleteAttachment(ctx context.Context, tx *sql.Tx, id uint64) error {
+	SQL := `DELETE FROM attachments WHERE id = ?`
+	_, err := tx.ExecContext(ctx, SQL, id)
+	if err != nil {
+		return errors.New("Failed to delete an attachment, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sweatsparks/internal/models"
+)
+
+type BlockRepository interface {
+	CreateBlock(ctx context.Context, tx *sql.Tx, block *models.Block) error
+	DeleteBlock(ctx context.Context, tx *sql.Tx, blockerID, blockedID uint64) (int64, error)
+	FindBlocksByBlockerID(ctx context.Context, tx *sql.Tx, blockerID uint64) ([]*models.Block, error)
+	IsBlocked(ctx context.Context, tx *sql.Tx, userID1, userID2 uint64) (bool, error)
+}
+
+type BlockRepositoryImpl struct{}
+
+func NewBlockRepository() BlockRepository {
+	return &BlockRepositoryImpl{}
+}
+
+func (repository *BlockRepositoryImpl) CreateBlock(ctx context.Context, tx *sql.Tx, block *models.Block) error {
+	SQL := `INSERT IGNORE INTO blocks (blocker_id, blocked_id, created_at) VALUES (?,?,?)`
+	_, err := tx.ExecContext(ctx, SQL, block.BlockerID, block.BlockedID, block.CreatedAt)
+	if err != nil {
+		return errors.New("Failed to create a block, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *BlockRepositoryImpl) DeleteBlock(ctx context.Context, tx *sql.Tx, blockerID, blockedID uint64) (int64, error) {
+	SQL := `DELETE FROM blocks WHERE blocker_id = ? AND blocked_id = ?`
+	response, err := tx.ExecContext(ctx, SQL, blockerID, blockedID)
+	if err != nil {
+		return 0, errors.New("Failed to delete a block, transaction rolled back. Reason: " + err.Error())
+	}
+	return response.RowsAffected()
+}
+
+func (repository *BlockRepositoryImpl) FindBlocksByBlockerID(ctx context.Context, tx *sql.Tx, blockerID uint64) ([]*models.Block, error) {
+	SQL := `SELECT blocker_id, blocked_id, created_at FROM blocks WHERE blocker_id = ? ORDER BY created_at DESC`
+	rows, err := tx.QueryContext(ctx, SQL, blockerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var blocks []*models.Block
+	for rows.Next() {
+		var block models.Block
+		if err := rows.Scan(&block.BlockerID, &block.BlockedID, &block.CreatedAt); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, &block)
+	}
+	return blocks, rows.Err()
+}
+
+// IsBlocked reports whether either user has blocked the other.
+func (repository *BlockRepositoryImpl) IsBlocked(ctx context.Context, tx *sql.Tx, userID1, userID2 uint64) (bool, error) {
+	SQL := `SELECT COUNT(*) FROM blocks WHERE (blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)`
+	var count int
+	if err := tx.QueryRowContext(ctx, SQL, userID1, userID2, userID2, userID1).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -5,12 +5,16 @@ import (
 	"database/sql"
 	"errors"
 	"sweatsparks/internal/models"
+	"time"
 )
 
 type MatchRepository interface {
 	CreateMatch(ctx context.Context, tx *sql.Tx, match *models.Match) error
+	FindMatchByID(ctx context.Context, tx *sql.Tx, matchID uint64) (*models.Match, error)
 	FindMatchByUserID(ctx context.Context, tx *sql.Tx, userID1, userID2 uint64) (*models.Match, error)
 	FindAllMatchByUserID(ctx context.Context, tx *sql.Tx, userID uint64) ([]*models.Match, error)
+	UpdateDisappearing(ctx context.Context, tx *sql.Tx, matchID uint64, after int64, trigger string) error
+	CloseMatch(ctx context.Context, tx *sql.Tx, matchID uint64, closedAt time.Time) error
 }
 
 type MatchRepositoryImpl struct {
@@ -20,8 +24,31 @@ func NewMatchRepository() MatchRepository {
 	return &MatchRepositoryImpl{}
 }
 
+// Every lookup leaves out closed matches, so once a match is closed the rest
+// of the app treats it as if it never existed.
+const matchColumns = `id, user_one_id, user_two_id, matched_at, disappear_after, disappear_trigger`
+
+func scanMatch(rows *sql.Rows) (*models.Match, error) {
+	var match models.Match
+	err := rows.Scan(
+		&match.Id,
+		&match.UserOne,
+		&match.UserTwo,
+		&match.MatchedTime,
+		&match.DisappearAfter,
+		&match.DisappearTrigger,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &match, nil
+}
+
+// CreateMatch stores a match for the pair. A pair has at most one open match,
+// so when one already exists, match takes its ID instead of a new one.
 func (repository *MatchRepositoryImpl) CreateMatch(ctx context.Context, tx *sql.Tx, match *models.Match) error {
-	SQL := `INSERT INTO matches (user_one_id, user_two_id, matched_at) VALUES (?,?,?)`
+	SQL := `INSERT INTO matches (user_one_id, user_two_id, matched_at) VALUES (?,?,?)
+		ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)`
 	response, err := tx.ExecContext(ctx, SQL, match.UserOne, match.UserTwo, match.MatchedTime)
 	if err != nil {
 		return errors.New("Failed to create a match, transaction rolled back. Reason: " + err.Error())
@@ -35,27 +62,37 @@ func (repository *MatchRepositoryImpl) CreateMatch(ctx context.Context, tx *sql.
 	return nil
 }
 
+func (repository *MatchRepositoryImpl) FindMatchByID(ctx context.Context, tx *sql.Tx, matchID uint64) (*models.Match, error) {
+	SQL := "select " + matchColumns + " from matches where id = ? and closed_at is null"
+	rows, err := tx.QueryContext(ctx, SQL, matchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanMatch(rows)
+	} else {
+		return nil, errors.New("match is not found")
+	}
+}
+
 func (repository *MatchRepositoryImpl) FindMatchByUserID(ctx context.Context, tx *sql.Tx, userID1, userID2 uint64) (*models.Match, error) {
-	SQL := "select id, user_one_id, user_two_id, matched_at from matches where (user_one_id = ? and user_two_id = ?) or (user_one_id = ? and user_two_id = ?)"
+	SQL := "select " + matchColumns + " from matches where ((user_one_id = ? and user_two_id = ?) or (user_one_id = ? and user_two_id = ?)) and closed_at is null"
 	rows, err := tx.QueryContext(ctx, SQL, userID1, userID2, userID2, userID1)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var match = models.Match{}
 	if rows.Next() {
-		err := rows.Scan(&match.Id, &match.UserOne, &match.UserTwo, &match.MatchedTime)
-		if err != nil {
-			return nil, err
-		}
-		return &match, nil
+		return scanMatch(rows)
 	} else {
 		return nil, errors.New("match is not found")
 	}
 }
 func (repository *MatchRepositoryImpl) FindAllMatchByUserID(ctx context.Context, tx *sql.Tx, userID uint64) ([]*models.Match, error) {
-	SQL := "select id, user_one_id, user_two_id, matched_at from matches where (user_one_id = ? or user_two_id = ?)"
+	SQL := "select " + matchColumns + " from matches where (user_one_id = ? or user_two_id = ?) and closed_at is null"
 	rows, err := tx.QueryContext(ctx, SQL, userID, userID)
 	if err != nil {
 		return nil, err
@@ -64,14 +101,32 @@ func (repository *MatchRepositoryImpl) FindAllMatchByUserID(ctx context.Context,
 
 	var matches []*models.Match
 	for rows.Next() {
-		var match models.Match
-		if err := rows.Scan(&match.Id, &match.UserOne, &match.UserTwo, &match.MatchedTime); err != nil {
+		match, err := scanMatch(rows)
+		if err != nil {
 			return nil, err
 		}
-		matches = append(matches, &match)
+		matches = append(matches, match)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 	return matches, nil
 }
+
+func (repository *MatchRepositoryImpl) UpdateDisappearing(ctx context.Context, tx *sql.Tx, matchID uint64, after int64, trigger string) error {
+	SQL := `UPDATE matches SET disappear_after = ?, disappear_trigger = ? WHERE id = ?`
+	_, err := tx.ExecContext(ctx, SQL, after, trigger, matchID)
+	if err != nil {
+		return errors.New("Failed to update disappearing messages, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *MatchRepositoryImpl) CloseMatch(ctx context.Context, tx *sql.Tx, matchID uint64, closedAt time.Time) error {
+	SQL := `UPDATE matches SET closed_at = ? WHERE id = ? AND closed_at IS NULL`
+	_, err := tx.ExecContext(ctx, SQL, closedAt, matchID)
+	if err != nil {
+		return errors.New("Failed to close a match, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
@@ -3,11 +3,28 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strings"
 	"sweatsparks/internal/models"
+	"time"
 )
 
 type MessageRepository interface {
-	GetMessageByMatchID(ctx context.Context, tx *sql.Tx, matchID int) ([]*models.Message, error)
+	CreateMessage(ctx context.Context, tx *sql.Tx, message *models.Message) error
+	FindMessageByClientID(ctx context.Context, tx *sql.Tx, senderID uint64, clientMessageID string) (*models.Message, error)
+	LockMessageByClientID(ctx context.Context, tx *sql.Tx, senderID uint64, clientMessageID string) (*models.Message, error)
+	FindMessageByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.Message, error)
+	FindMessagesByMatchID(ctx context.Context, tx *sql.Tx, matchID, viewerID, before, after uint64, forward bool, limit int) ([]*models.Message, error)
+	MarkMessagesDelivered(ctx context.Context, tx *sql.Tx, matchID, recipientID, upToID uint64, at time.Time) (int64, error)
+	MarkMessagesRead(ctx context.Context, tx *sql.Tx, matchID, readerID, upToID uint64, at time.Time) (int64, error)
+	CountUnreadMessages(ctx context.Context, tx *sql.Tx, matchID, userID uint64) (int, error)
+	UpdateMessageContent(ctx context.Context, tx *sql.Tx, id uint64, content string, editedAt time.Time) error
+	CreateMessageEdit(ctx context.Context, tx *sql.Tx, edit *models.MessageEdit) error
+	FindMessageEdits(ctx context.Context, tx *sql.Tx, messageID uint64) ([]*models.MessageEdit, error)
+	UnsendMessage(ctx context.Context, tx *sql.Tx, id uint64, deletedAt time.Time) error
+	HideMessage(ctx context.Context, tx *sql.Tx, id, userID uint64, hiddenAt time.Time) error
+	FindExpiredMessages(ctx context.Context, tx *sql.Tx, now time.Time, limit int) ([]*models.Message, error)
+	DeleteMessages(ctx context.Context, tx *sql.Tx, ids []uint64) error
 }
 
 type MessageRepositoryImpl struct{}
@@ -16,28 +33,300 @@ func NewMessageRepository() MessageRepository {
 	return &MessageRepositoryImpl{}
 }
 
-func (repository *MessageRepositoryImpl) GetMessageByMatchID(ctx context.Context, tx *sql.Tx, matchID int) ([]*models.Message, error) {
-	SQL := `SELECT id, match_id, sender_id, content, sent_at FROM messages WHERE match_id = ? ORDER BY sent_at ASC`
+const messageColumns = `id, match_id, sender_id, type, client_message_id, content, attachment_id, sent_at, delivered_at, read_at, edited_at, deleted_at, expire_after, expires_at`
 
-	rows, err := tx.QueryContext(ctx, SQL, matchID)
+func scanMessage(rows *sql.Rows) (*models.Message, error) {
+	var message models.Message
+	err := rows.Scan(
+		&message.Id,
+		&message.MatchID,
+		&message.SenderID,
+		&message.Type,
+		&message.ClientMessageID,
+		&message.Content,
+		&message.AttachmentID,
+		&message.SendAt,
+		&message.DeliveredAt,
+		&message.ReadAt,
+		&message.EditedAt,
+		&message.DeletedAt,
+		&message.ExpireAfter,
+		&message.ExpiresAt,
+	)
 	if err != nil {
 		return nil, err
 	}
+	return &message, nil
+}
+
+func (repository *MessageRepositoryImpl) CreateMessage(ctx context.Context, tx *sql.Tx, message *models.Message) error {
+	SQL := `INSERT INTO messages (match_id, sender_id, type, client_message_id, content, attachment_id, sent_at, expire_after, expires_at) VALUES (?,?,?,?,?,?,?,?,?)`
+	response, err := tx.ExecContext(ctx, SQL,
+		message.MatchID,
+		message.SenderID,
+		message.Type,
+		message.ClientMessageID,
+		message.Content,
+		message.AttachmentID,
+		message.SendAt,
+		message.ExpireAfter,
+		message.ExpiresAt,
+	)
+	if err != nil {
+		return errors.New("Failed to create a message, transaction rolled back. Reason: " + err.Error())
+	}
+	messageID, err := response.LastInsertId()
+	if err != nil {
+		return errors.New("Failed to retrieve message_id, transaction rolled back. Reason:" + err.Error())
+	}
+
+	message.Id = uint64(messageID)
+	return nil
+}
+
+func (repository *MessageRepositoryImpl) FindMessageByClientID(ctx context.Context, tx *sql.Tx, senderID uint64, clientMessageID string) (*models.Message, error) {
+	SQL := `SELECT ` + messageColumns + ` FROM messages WHERE sender_id = ? AND client_message_id = ?`
+	return findMessageByClientID(ctx, tx, SQL, senderID, clientMessageID)
+}
+
+// LockMessageByClientID is FindMessageByClientID as a locking read, so it also
+// sees a row committed by another transaction after this one took its
+// snapshot.
+func (repository *MessageRepositoryImpl) LockMessageByClientID(ctx context.Context, tx *sql.Tx, senderID uint64, clientMessageID string) (*models.Message, error) {
+	SQL := `SELECT ` + messageColumns + ` FROM messages WHERE sender_id = ? AND client_message_id = ? FOR UPDATE`
+	return findMessageByClientID(ctx, tx, SQL, senderID, clientMessageID)
+}
+
+func findMessageByClientID(ctx context.Context, tx *sql.Tx, SQL string, senderID uint64, clientMessageID string) (*models.Message, error) {
+	rows, err := tx.QueryContext(ctx, SQL, senderID, clientMessageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanMessage(rows)
+	} else {
+		return nil, errors.New("message is not found")
+	}
+}
+
+func (repository *MessageRepositoryImpl) FindMessageByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.Message, error) {
+	SQL := `SELECT ` + messageColumns + ` FROM messages WHERE id = ?`
+	rows, err := tx.QueryContext(ctx, SQL, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanMessage(rows)
+	} else {
+		return nil, errors.New("message is not found")
+	}
+}
+
+// FindMessagesByMatchID returns up to limit messages of a match, newest first.
+// It leaves out messages the viewer deleted for themselves, expired messages
+// the sweeper has not removed yet and messages from shadow-banned users other
+// than the viewer. With before set it pages back through older messages; with
+// forward set it returns the oldest messages newer than after, still ordered
+// newest first, so an after of 0 starts from the first message.
+func (repository *MessageRepositoryImpl) FindMessagesByMatchID(ctx context.Context, tx *sql.Tx, matchID, viewerID, before, after uint64, forward bool, limit int) ([]*models.Message, error) {
+	SQL := `SELECT ` + messageColumns + ` FROM messages WHERE match_id = ?
+		AND id NOT IN (SELECT message_id FROM message_hidden WHERE user_id = ?)
+		AND (expires_at IS NULL OR expires_at > ?)
+		AND (sender_id = ? OR sender_id NOT IN (SELECT id FROM users WHERE shadow_banned_at IS NOT NULL))`
+	args := []interface{}{matchID, viewerID, time.Now(), viewerID}
+
+	switch {
+	case forward:
+		SQL += ` AND id > ? ORDER BY id ASC LIMIT ?`
+		args = append(args, after, limit)
+	case before > 0:
+		SQL += ` AND id < ? ORDER BY id DESC LIMIT ?`
+		args = append(args, before, limit)
+	default:
+		SQL += ` ORDER BY id DESC LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := tx.QueryContext(ctx, SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
 	var messages []*models.Message
 	for rows.Next() {
-		var message models.Message
-		if err := rows.Scan(
-			&message.Id,
-			&message.MatchID,
-			&message.SendAt,
-			&message.Content,
-			&message.SendAt,
-		); err != nil {
+		message, err := scanMessage(rows)
+		if err != nil {
 			return nil, err
 		}
 
-		messages = append(messages, &message)
+		messages = append(messages, message)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if forward {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
 	}
 	return messages, nil
 }
+
+func (repository *MessageRepositoryImpl) MarkMessagesDelivered(ctx context.Context, tx *sql.Tx, matchID, recipientID, upToID uint64, at time.Time) (int64, error) {
+	SQL := `UPDATE messages SET delivered_at = ? WHERE match_id = ? AND sender_id <> ? AND id <= ? AND delivered_at IS NULL`
+	response, err := tx.ExecContext(ctx, SQL, at, matchID, recipientID, upToID)
+	if err != nil {
+		return 0, errors.New("Failed to mark messages delivered, transaction rolled back. Reason: " + err.Error())
+	}
+	return response.RowsAffected()
+}
+
+func (repository *MessageRepositoryImpl) MarkMessagesRead(ctx context.Context, tx *sql.Tx, matchID, readerID, upToID uint64, at time.Time) (int64, error) {
+	SQL := `UPDATE messages SET read_at = ?, delivered_at = COALESCE(delivered_at, ?),
+		expires_at = IF(expire_after IS NULL, expires_at, DATE_ADD(?, INTERVAL expire_after SECOND))
+		WHERE match_id = ? AND sender_id <> ? AND id <= ? AND read_at IS NULL`
+	response, err := tx.ExecContext(ctx, SQL, at, at, at, matchID, readerID, upToID)
+	if err != nil {
+		return 0, errors.New("Failed to mark messages read, transaction rolled back. Reason: " + err.Error())
+	}
+	return response.RowsAffected()
+}
+
+func (repository *MessageRepositoryImpl) CountUnreadMessages(ctx context.Context, tx *sql.Tx, matchID, userID uint64) (int, error) {
+	SQL := `SELECT COUNT(*) FROM messages WHERE match_id = ? AND sender_id <> ? AND read_at IS NULL AND deleted_at IS NULL
+		AND id NOT IN (SELECT message_id FROM message_hidden WHERE user_id = ?)
+		AND sender_id NOT IN (SELECT id FROM users WHERE shadow_banned_at IS NOT NULL)`
+	var count int
+	err := tx.QueryRowContext(ctx, SQL, matchID, userID, userID).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (repository *MessageRepositoryImpl) UpdateMessageContent(ctx context.Context, tx *sql.Tx, id uint64, content string, editedAt time.Time) error {
+	SQL := `UPDATE messages SET content = ?, edited_at = ? WHERE id = ?`
+	_, err := tx.ExecContext(ctx, SQL, content, editedAt, id)
+	if err != nil {
+		return errors.New("Failed to edit a message, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *MessageRepositoryImpl) CreateMessageEdit(ctx context.Context, tx *sql.Tx, edit *models.MessageEdit) error {
+	SQL := `INSERT INTO message_edits (message_id, content, edited_at) VALUES (?,?,?)`
+	response, err := tx.ExecContext(ctx, SQL, edit.MessageID, edit.Content, edit.EditedAt)
+	if err != nil {
+		return errors.New("Failed to create a message edit, transaction rolled back. Reason: " + err.Error())
+	}
+	editID, err := response.LastInsertId()
+	if err != nil {
+		return errors.New("Failed to retrieve message_edit_id, transaction rolled back. Reason:" + err.Error())
+	}
+
+	edit.Id = uint64(editID)
+	return nil
+}
+
+func (repository *MessageRepositoryImpl) FindMessageEdits(ctx context.Context, tx *sql.Tx, messageID uint64) ([]*models.MessageEdit, error) {
+	SQL := `SELECT id, message_id, content, edited_at FROM message_edits WHERE message_id = ? ORDER BY id ASC`
+	rows, err := tx.QueryContext(ctx, SQL, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edits []*models.MessageEdit
+	for rows.Next() {
+		var edit models.MessageEdit
+		if err := rows.Scan(&edit.Id, &edit.MessageID, &edit.Content, &edit.EditedAt); err != nil {
+			return nil, err
+		}
+		edits = append(edits, &edit)
+	}
+	return edits, rows.Err()
+}
+
+// UnsendMessage soft deletes a message for both participants. The content,
+// attachment reference, edit history and reactions are dropped so nothing of
+// it remains.
+func (repository *MessageRepositoryImpl) UnsendMessage(ctx context.Context, tx *sql.Tx, id uint64, deletedAt time.Time) error {
+	SQL := `UPDATE messages SET content = '', attachment_id = NULL, deleted_at = ? WHERE id = ?`
+	_, err := tx.ExecContext(ctx, SQL, deletedAt, id)
+	if err != nil {
+		return errors.New("Failed to unsend a message, transaction rolled back. Reason: " + err.Error())
+	}
+
+	SQL = `DELETE FROM message_edits WHERE message_id = ?`
+	_, err = tx.ExecContext(ctx, SQL, id)
+	if err != nil {
+		return errors.New("Failed to delete message edits, transaction rolled back. Reason: " + err.Error())
+	}
+
+	SQL = `DELETE FROM message_reactions WHERE message_id = ?`
+	_, err = tx.ExecContext(ctx, SQL, id)
+	if err != nil {
+		return errors.New("Failed to delete message reactions, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *MessageRepositoryImpl) HideMessage(ctx context.Context, tx *sql.Tx, id, userID uint64, hiddenAt time.Time) error {
+	SQL := `INSERT IGNORE INTO message_hidden (message_id, user_id, hidden_at) VALUES (?,?,?)`
+	_, err := tx.ExecContext(ctx, SQL, id, userID, hiddenAt)
+	if err != nil {
+		return errors.New("Failed to delete a message, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *MessageRepositoryImpl) FindExpiredMessages(ctx context.Context, tx *sql.Tx, now time.Time, limit int) ([]*models.Message, error) {
+	SQL := `SELECT ` + messageColumns + ` FROM messages WHERE expires_at <= ? ORDER BY expires_at ASC LIMIT ?`
+	rows, err := tx.QueryContext(ctx, SQL, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*models.Message
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+// DeleteMessages hard deletes messages together with their edit history,
+// reactions and per-user hides. Attachments are left to the caller.
+func (repository *MessageRepositoryImpl) DeleteMessages(ctx context.Context, tx *sql.Tx, ids []uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	in := `(?` + strings.Repeat(",?", len(ids)-1) + `)`
+
+	for _, SQL := range []string{
+		`DELETE FROM message_edits WHERE message_id IN ` + in,
+		`DELETE FROM message_reactions WHERE message_id IN ` + in,
+		`DELETE FROM message_hidden WHERE message_id IN ` + in,
+		`DELETE FROM messages WHERE id IN ` + in,
+	} {
+		if _, err := tx.ExecContext(ctx, SQL, args...); err != nil {
+			return errors.New("Failed to delete messages, transaction rolled back. Reason: " + err.Error())
+		}
+	}
+	return nil
+}
@@ -4,15 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"sweatsparks/internal/models"
+	"time"
 )
 
 type ProfileRepository interface {
 	CreateProfileByUserID(ctx context.Context, tx *sql.Tx, profile *models.Profile) error
 	FindProfileByUserID(ctx context.Context, tx *sql.Tx, userID int) (*models.Profile, error)
-	FindAllProfileByLocationGender(ctx context.Context, tx *sql.Tx, location, gender string) ([]*models.Profile, error)
+	FindAllProfileByLocationGender(ctx context.Context, tx *sql.Tx, viewerID uint64, location, gender string) ([]*models.Profile, error)
 	UpdateProfileByUserID(ctx context.Context, tx *sql.Tx, profile *models.Profile) error
 	StorePhotoByUserID(ctx context.Context, tx *sql.Tx, photo *models.Photo) error
+	FindFirstNamesByUserIDs(ctx context.Context, tx *sql.Tx, userIDs []uint64) (map[uint64]string, error)
+	FindPhotoByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.Photo, error)
+	FindPhotosByUserID(ctx context.Context, tx *sql.Tx, userID uint64) ([]*models.Photo, error)
 }
 
 type ProfileRepositoryImpl struct {
@@ -23,7 +28,7 @@ func NewProfileRepository() ProfileRepository {
 }
 
 func (repository *ProfileRepositoryImpl) CreateProfileByUserID(ctx context.Context, tx *sql.Tx, profile *models.Profile) error {
-	SQL := `INSERT INTO profiles (user_id,first_name,last_name,gender,gender_preference,date_of_birth,bio,location,interests) VALUES (?,?,?,?,?,?,?,?,?,?,?)`
+	SQL := `INSERT INTO profiles (user_id,first_name,last_name,gender,gender_preference,date_of_birth,bio,location,interests) VALUES (?,?,?,?,?,?,?,?,?)`
 
 	_, err := tx.ExecContext(ctx, SQL,
 		profile.UserID,
@@ -49,18 +54,19 @@ func (repository *ProfileRepositoryImpl) FindProfileByUserID(ctx context.Context
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	if rows.Next() {
 		var profile models.Profile
 		err := rows.Scan(&profile.UserID,
-			profile.FirstName,
-			profile.LastName,
-			profile.Gender,
-			profile.GenderPreference,
-			profile.BirthDate,
-			profile.Bio,
-			profile.Location,
-			profile.Interest,
+			&profile.FirstName,
+			&profile.LastName,
+			&profile.Gender,
+			&profile.GenderPreference,
+			&profile.BirthDate,
+			&profile.Bio,
+			&profile.Location,
+			&profile.Interest,
 		)
 		if err != nil {
 			return nil, err
@@ -70,26 +76,36 @@ func (repository *ProfileRepositoryImpl) FindProfileByUserID(ctx context.Context
 		return nil, errors.New("user id not found in profile")
 	}
 }
-func (repository *ProfileRepositoryImpl) FindAllProfileByLocationGender(ctx context.Context, tx *sql.Tx, location, gender string) ([]*models.Profile, error) {
-	SQL := `SELECT user_id,first_name,last_name,gender,gender_preference,date_of_birth,bio,location,interests FROM profiles WHERE location = ? AND gender = ?`
 
-	rows, err := tx.QueryContext(ctx, SQL, location, gender)
+// FindAllProfileByLocationGender lists the profiles the viewer can discover,
+// leaving out their own, suspended, banned and shadow-banned accounts and
+// anyone on either side of a block with them.
+func (repository *ProfileRepositoryImpl) FindAllProfileByLocationGender(ctx context.Context, tx *sql.Tx, viewerID uint64, location, gender string) ([]*models.Profile, error) {
+	SQL := `SELECT user_id,first_name,last_name,gender,gender_preference,date_of_birth,bio,location,interests FROM profiles
+		WHERE location = ? AND gender = ? AND user_id <> ?
+		AND user_id NOT IN (SELECT id FROM users WHERE banned_at IS NOT NULL OR shadow_banned_at IS NOT NULL
+			OR (suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > ?)))
+		AND user_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = ?)
+		AND user_id NOT IN (SELECT blocker_id FROM blocks WHERE blocked_id = ?)`
+
+	rows, err := tx.QueryContext(ctx, SQL, location, gender, viewerID, time.Now(), viewerID, viewerID)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	var profiles []*models.Profile
 	for rows.Next() {
 		var profile models.Profile
 		if err := rows.Scan(&profile.UserID,
-			profile.FirstName,
-			profile.LastName,
-			profile.Gender,
-			profile.GenderPreference,
-			profile.BirthDate,
-			profile.Bio,
-			profile.Location,
-			profile.Interest,
+			&profile.FirstName,
+			&profile.LastName,
+			&profile.Gender,
+			&profile.GenderPreference,
+			&profile.BirthDate,
+			&profile.Bio,
+			&profile.Location,
+			&profile.Interest,
 		); err != nil {
 			return nil, err
 		}
@@ -139,3 +155,72 @@ func (repository *ProfileRepositoryImpl) StorePhotoByUserID(ctx context.Context,
 	photo.Id = uint64(photoID)
 	return nil
 }
+
+// FindFirstNamesByUserIDs returns the first name of each user that has a
+// profile, keyed by user ID.
+func (repository *ProfileRepositoryImpl) FindFirstNamesByUserIDs(ctx context.Context, tx *sql.Tx, userIDs []uint64) (map[uint64]string, error) {
+	names := make(map[uint64]string)
+	if len(userIDs) == 0 {
+		return names, nil
+	}
+
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		args[i] = id
+	}
+
+	SQL := `SELECT user_id, first_name FROM profiles WHERE user_id IN (?` + strings.Repeat(",?", len(userIDs)-1) + `)`
+	rows, err := tx.QueryContext(ctx, SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID uint64
+		var name string
+		if err := rows.Scan(&userID, &name); err != nil {
+			return nil, err
+		}
+		names[userID] = name
+	}
+	return names, rows.Err()
+}
+
+func (repository *ProfileRepositoryImpl) FindPhotoByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.Photo, error) {
+	SQL := `SELECT id, user_id, url, is_primary, uploaded_at FROM photos WHERE id = ?`
+	rows, err := tx.QueryContext(ctx, SQL, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var photo models.Photo
+		if err := rows.Scan(&photo.Id, &photo.UserID, &photo.URL, &photo.IsPrimary, &photo.UploadedAt); err != nil {
+			return nil, err
+		}
+		return &photo, nil
+	} else {
+		return nil, errors.New("photo is not found")
+	}
+}
+
+func (repository *ProfileRepositoryImpl) FindPhotosByUserID(ctx context.Context, tx *sql.Tx, userID uint64) ([]*models.Photo, error) {
+	SQL := `SELECT id, user_id, url, is_primary, uploaded_at FROM photos WHERE user_id = ? ORDER BY id`
+	rows, err := tx.QueryContext(ctx, SQL, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var photos []*models.Photo
+	for rows.Next() {
+		var photo models.Photo
+		if err := rows.Scan(&photo.Id, &photo.UserID, &photo.URL, &photo.IsPrimary, &photo.UploadedAt); err != nil {
+			return nil, err
+		}
+		photos = append(photos, &photo)
+	}
+	return photos, rows.Err()
+}
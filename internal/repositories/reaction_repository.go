@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sweatsparks/internal/models"
+)
+
+type ReactionRepository interface {
+	SaveReaction(ctx context.Context, tx *sql.Tx, reaction *models.Reaction) error
+	FindReaction(ctx context.Context, tx *sql.Tx, messageID, userID uint64) (*models.Reaction, error)
+	FindReactionsByMessageIDs(ctx context.Context, tx *sql.Tx, messageIDs []uint64) ([]*models.Reaction, error)
+	DeleteReaction(ctx context.Context, tx *sql.Tx, messageID, userID uint64) (int64, error)
+}
+
+type ReactionRepositoryImpl struct{}
+
+func NewReactionRepository() ReactionRepository {
+	return &ReactionRepositoryImpl{}
+}
+
+const reactionColumns = `message_id, user_id, emoji, created_at`
+
+func scanReaction(rows *sql.Rows) (*models.Reaction, error) {
+	var reaction models.Reaction
+	err := rows.Scan(
+		&reaction.MessageID,
+		&reaction.UserID,
+		&reaction.Emoji,
+		&reaction.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &reaction, nil
+}
+
+// SaveReaction adds the user's reaction to a message or replaces the one they
+// already had.
+func (repository *ReactionRepositoryImpl) SaveReaction(ctx context.Context, tx *sql.Tx, reaction *models.Reaction) error {
+	SQL := `INSERT INTO message_reactions (message_id, user_id, emoji, created_at) VALUES (?,?,?,?)
+		ON DUPLICATE KEY UPDATE emoji = VALUES(emoji), created_at = VALUES(created_at)`
+	_, err := tx.ExecContext(ctx, SQL, reaction.MessageID, reaction.UserID, reaction.Emoji, reaction.CreatedAt)
+	if err != nil {
+		return errors.New("Failed to save a reaction, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *ReactionRepositoryImpl) FindReaction(ctx context.Context, tx *sql.Tx, messageID, userID uint64) (*models.Reaction, error) {
+	SQL := `SELECT ` + reactionColumns + ` FROM message_reactions WHERE message_id = ? AND user_id = ?`
+	rows, err := tx.QueryContext(ctx, SQL, messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanReaction(rows)
+	} else {
+		return nil, errors.New("reaction is not found")
+	}
+}
+
+// FindReactionsByMessageIDs returns the reactions on the given messages,
+// oldest first.
+func (repository *ReactionRepositoryImpl) FindReactionsByMessageIDs(ctx context.Context, tx *sql.Tx, messageIDs []uint64) ([]*models.Reaction, error) {
+	if len(messageIDs) == 0 {
+		return nil, nil
+	}
+
+	args := make([]interface{}, len(messageIDs))
+	for i, id := range messageIDs {
+		args[i] = id
+	}
+
+	SQL := `SELECT ` + reactionColumns + ` FROM message_reactions WHERE message_id IN (?` + strings.Repeat(",?", len(messageIDs)-1) + `) ORDER BY created_at ASC`
+	rows, err := tx.QueryContext(ctx, SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reactions []*models.Reaction
+	for rows.Next() {
+		reaction, err := scanReaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		reactions = append(reactions, reaction)
+	}
+	return reactions, rows.Err()
+}
+
+func (repository *ReactionRepositoryImpl) DeleteReaction(ctx context.Context, tx *sql.Tx, messageID, userID uint64) (int64, error) {
+	SQL := `DELETE FROM message_reactions WHERE message_id = ? AND user_id = ?`
+	response, err := tx.ExecContext(ctx, SQL, messageID, userID)
+	if err != nil {
+		return 0, errors.New("Failed to delete a reaction, transaction rolled back. Reason: " + err.Error())
+	}
+	return response.RowsAffected()
+}
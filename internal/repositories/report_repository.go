@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sweatsparks/internal/models"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ErrDuplicateReport is returned by CreateReport when the reporter already has
+// an open report on the target.
+var ErrDuplicateReport = errors.New("report is already open")
+
+// mysqlDuplicateEntry is the MySQL error number for a unique key violation.
+const mysqlDuplicateEntry = 1062
+
+type ReportRepository interface {
+	CreateReport(ctx context.Context, tx *sql.Tx, report *models.Report) error
+	FindReportByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.Report, error)
+	FindOpenReport(ctx context.Context, tx *sql.Tx, reporterID uint64, targetType string, targetID uint64) (*models.Report, error)
+	FindReportsByStatus(ctx context.Context, tx *sql.Tx, status string, limit, offset int) ([]*models.Report, error)
+	UpdateReportStatus(ctx context.Context, tx *sql.Tx, id uint64, status string, reviewerID uint64, note string, reviewedAt time.Time) error
+}
+
+type ReportRepositoryImpl struct{}
+
+func NewReportRepository() ReportRepository {
+	return &ReportRepositoryImpl{}
+}
+
+const reportColumns = `id, reporter_id, reported_user_id, target_type, target_id, reason, details, snapshot, status, reviewer_id, review_note, reviewed_at, created_at`
+
+func scanReport(rows *sql.Rows) (*models.Report, error) {
+	var report models.Report
+	err := rows.Scan(
+		&report.Id,
+		&report.ReporterID,
+		&report.ReportedUserID,
+		&report.TargetType,
+		&report.TargetID,
+		&report.Reason,
+		&report.Details,
+		&report.Snapshot,
+		&report.Status,
+		&report.ReviewerID,
+		&report.ReviewNote,
+		&report.ReviewedAt,
+		&report.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (repository *ReportRepositoryImpl) CreateReport(ctx context.Context, tx *sql.Tx, report *models.Report) error {
+	SQL := `INSERT INTO reports (reporter_id, reported_user_id, target_type, target_id, reason, details, snapshot, status, created_at) VALUES (?,?,?,?,?,?,?,?,?)`
+	response, err := tx.ExecContext(ctx, SQL,
+		report.ReporterID,
+		report.ReportedUserID,
+		report.TargetType,
+		report.TargetID,
+		report.Reason,
+		report.Details,
+		report.Snapshot,
+		report.Status,
+		report.CreatedAt,
+	)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return ErrDuplicateReport
+	}
+	if err != nil {
+		return errors.New("Failed to create a report, transaction rolled back. Reason: " + err.Error())
+	}
+	reportID, err := response.LastInsertId()
+	if err != nil {
+		return errors.New("Failed to retrieve report_id, transaction rolled back. Reason:" + err.Error())
+	}
+
+	report.Id = uint64(reportID)
+	return nil
+}
+
+func (repository *ReportRepositoryImpl) FindReportByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.Report, error) {
+	SQL := `SELECT ` + reportColumns + ` FROM reports WHERE id = ?`
+	rows, err := tx.QueryContext(ctx, SQL, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanReport(rows)
+	} else {
+		return nil, errors.New("report is not found")
+	}
+}
+
+// FindOpenReport returns sql.ErrNoRows when the reporter has no open report on
+// the target.
+func (repository *ReportRepositoryImpl) FindOpenReport(ctx context.Context, tx *sql.Tx, reporterID uint64, targetType string, targetID uint64) (*models.Report, error) {
+	SQL := `SELECT ` + reportColumns + ` FROM reports WHERE reporter_id = ? AND target_type = ? AND target_id = ? AND status = ?`
+	rows, err := tx.QueryContext(ctx, SQL, reporterID, targetType, targetID, models.ReportStatusOpen)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanReport(rows)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return nil, sql.ErrNoRows
+}
+
+// FindReportsByStatus lists reports oldest first, so the moderation queue is
+// worked through in the order reports came in.
+func (repository *ReportRepositoryImpl) FindReportsByStatus(ctx context.Context, tx *sql.Tx, status string, limit, offset int) ([]*models.Report, error) {
+	SQL := `SELECT ` + reportColumns + ` FROM reports WHERE status = ? ORDER BY id ASC LIMIT ? OFFSET ?`
+	rows, err := tx.QueryContext(ctx, SQL, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []*models.Report
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
+
+func (repository *ReportRepositoryImpl) UpdateReportStatus(ctx context.Context, tx *sql.Tx, id uint64, status string, reviewerID uint64, note string, reviewedAt time.Time) error {
+	SQL := `UPDATE reports SET status = ?, reviewer_id = ?, review_note = ?, reviewed_at = ? WHERE id = ?`
+	_, err := tx.ExecContext(ctx, SQL, status, reviewerID, note, reviewedAt, id)
+	if err != nil {
+		return errors.New("Failed to update a report, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
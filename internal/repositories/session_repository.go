@@ -0,0 +1,171 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sweatsparks/internal/models"
+	"time"
+)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, tx *sql.Tx, session *models.Session) error
+	FindSessionByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.Session, error)
+	FindActiveSessionsByUserID(ctx context.Context, tx *sql.Tx, userID uint64, usedSince time.Time) ([]*models.Session, error)
+	TouchSession(ctx context.Context, tx *sql.Tx, session *models.Session) error
+	RevokeSession(ctx context.Context, tx *sql.Tx, id uint64, revokedAt time.Time) error
+	RevokeOtherSessions(ctx context.Context, tx *sql.Tx, userID, keepID uint64, revokedAt time.Time) error
+	CreateRefreshToken(ctx context.Context, tx *sql.Tx, token *models.RefreshToken) error
+	FindRefreshTokenByHash(ctx context.Context, tx *sql.Tx, tokenHash string) (*models.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, tx *sql.Tx, id uint64, usedAt time.Time) (bool, error)
+}
+
+type SessionRepositoryImpl struct{}
+
+func NewSessionRepository() SessionRepository {
+	return &SessionRepositoryImpl{}
+}
+
+const sessionColumns = `id, user_id, device_label, user_agent, ip_address, created_at, last_used_at, revoked_at`
+
+func scanSession(rows *sql.Rows) (*models.Session, error) {
+	var session models.Session
+	err := rows.Scan(&session.Id, &session.UserID, &session.DeviceLabel, &session.UserAgent, &session.IPAddress, &session.CreatedAt, &session.LastUsedAt, &session.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (repository *SessionRepositoryImpl) CreateSession(ctx context.Context, tx *sql.Tx, session *models.Session) error {
+	SQL := `INSERT INTO sessions (user_id, device_label, user_agent, ip_address, created_at, last_used_at) VALUES (?,?,?,?,?,?)`
+	response, err := tx.ExecContext(ctx, SQL, session.UserID, session.DeviceLabel, session.UserAgent, session.IPAddress, session.CreatedAt, session.LastUsedAt)
+	if err != nil {
+		return errors.New("Failed to create a session, transaction rolled back. Reason: " + err.Error())
+	}
+	sessionID, err := response.LastInsertId()
+	if err != nil {
+		return errors.New("Failed to retrieve session_id, transaction rolled back. Reason:" + err.Error())
+	}
+
+	session.Id = uint64(sessionID)
+	return nil
+}
+
+func (repository *SessionRepositoryImpl) FindSessionByID(ctx context.Context, tx *sql.Tx, id uint64) (*models.Session, error) {
+	SQL := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = ?`
+	rows, err := tx.QueryContext(ctx, SQL, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return scanSession(rows)
+	} else {
+		return nil, errors.New("session is not found")
+	}
+}
+
+// FindActiveSessionsByUserID lists the sessions of a user that are not revoked
+// and were used after usedSince, most recently used first.
+func (repository *SessionRepositoryImpl) FindActiveSessionsByUserID(ctx context.Context, tx *sql.Tx, userID uint64, usedSince time.Time) ([]*models.Session, error) {
+	SQL := `SELECT ` + sessionColumns + ` FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND last_used_at > ?
+		ORDER BY last_used_at DESC`
+	rows, err := tx.QueryContext(ctx, SQL, userID, usedSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*models.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// TouchSession records that a session was just used, and from where.
+func (repository *SessionRepositoryImpl) TouchSession(ctx context.Context, tx *sql.Tx, session *models.Session) error {
+	SQL := `UPDATE sessions SET user_agent = ?, ip_address = ?, last_used_at = ? WHERE id = ?`
+	_, err := tx.ExecContext(ctx, SQL, session.UserAgent, session.IPAddress, session.LastUsedAt, session.Id)
+	if err != nil {
+		return errors.New("Failed to update a session, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *SessionRepositoryImpl) RevokeSession(ctx context.Context, tx *sql.Tx, id uint64, revokedAt time.Time) error {
+	SQL := `UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`
+	_, err := tx.ExecContext(ctx, SQL, revokedAt, id)
+	if err != nil {
+		return errors.New("Failed to revoke a session, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+// RevokeOtherSessions revokes every session of a user except keepID.
+func (repository *SessionRepositoryImpl) RevokeOtherSessions(ctx context.Context, tx *sql.Tx, userID, keepID uint64, revokedAt time.Time) error {
+	SQL := `UPDATE sessions SET revoked_at = ? WHERE user_id = ? AND id <> ? AND revoked_at IS NULL`
+	_, err := tx.ExecContext(ctx, SQL, revokedAt, userID, keepID)
+	if err != nil {
+		return errors.New("Failed to revoke sessions, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *SessionRepositoryImpl) CreateRefreshToken(ctx context.Context, tx *sql.Tx, token *models.RefreshToken) error {
+	SQL := `INSERT INTO refresh_tokens (session_id, parent_id, token_hash, created_at, expires_at) VALUES (?,?,?,?,?)`
+	response, err := tx.ExecContext(ctx, SQL, token.SessionID, token.ParentID, token.TokenHash, token.CreatedAt, token.ExpiresAt)
+	if err != nil {
+		return errors.New("Failed to create a refresh token, transaction rolled back. Reason: " + err.Error())
+	}
+	tokenID, err := response.LastInsertId()
+	if err != nil {
+		return errors.New("Failed to retrieve refresh_token_id, transaction rolled back. Reason:" + err.Error())
+	}
+
+	token.Id = uint64(tokenID)
+	return nil
+}
+
+func (repository *SessionRepositoryImpl) FindRefreshTokenByHash(ctx context.Context, tx *sql.Tx, tokenHash string) (*models.RefreshToken, error) {
+	SQL := `SELECT id, session_id, parent_id, token_hash, created_at, expires_at, used_at FROM refresh_tokens WHERE token_hash = ?`
+	rows, err := tx.QueryContext(ctx, SQL, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var token models.RefreshToken
+		err := rows.Scan(&token.Id, &token.SessionID, &token.ParentID, &token.TokenHash, &token.CreatedAt, &token.ExpiresAt, &token.UsedAt)
+		if err != nil {
+			return nil, err
+		}
+		return &token, nil
+	} else {
+		return nil, errors.New("refresh token is not found")
+	}
+}
+
+// MarkRefreshTokenUsed spends a refresh token. It reports false when the token
+// had already been spent, which is how two racing refreshes with the same
+// token are told apart.
+func (repository *SessionRepositoryImpl) MarkRefreshTokenUsed(ctx context.Context, tx *sql.Tx, id uint64, usedAt time.Time) (bool, error) {
+	SQL := `UPDATE refresh_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL`
+	result, err := tx.ExecContext(ctx, SQL, usedAt, id)
+	if err != nil {
+		return false, errors.New("Failed to use a refresh token, transaction rolled back. Reason: " + err.Error())
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
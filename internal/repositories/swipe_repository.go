@@ -37,42 +37,38 @@ func (repository *SwipeRepositoryImpl) CreateSwipe(ctx context.Context, tx *sql.
 	return nil
 }
 
+// FindSwipe returns the latest swipe from swiper on swipee, or sql.ErrNoRows
+// when there is none.
 func (repository *SwipeRepositoryImpl) FindSwipe(ctx context.Context, tx *sql.Tx, swiper, swipee int) (*models.Swipe, error) {
-	sql := `SELECT swiper_id, swipee_id, direction, swiped_at FROM swipes WHERE swiper_id = ? AND swipee_id = ?`
+	sql := `SELECT id, swiper_id, swipee_id, direction, swiped_at FROM swipes WHERE swiper_id = ? AND swipee_id = ? ORDER BY swiped_at DESC LIMIT 1`
 
-	rows, err := tx.QueryContext(ctx, sql, swipee, swiper)
+	var swipe models.Swipe
+	err := tx.QueryRowContext(ctx, sql, swiper, swipee).Scan(&swipe.Id, &swipe.SwiperID, &swipe.SwipeeID, &swipe.Direction, &swipe.SwipedAt)
 	if err != nil {
 		return nil, err
 	}
-
-	var swipe models.Swipe
-	if rows.Next() {
-		err := rows.Scan(swipe.SwiperID, swipe.SwipeeID, swipe.Direction, swipe.SwipedAt)
-		if err != nil {
-			return nil, err
-		}
-		return &swipe, nil
-	} else {
-		return nil, errors.New("swipe is not found")
-	}
+	return &swipe, nil
 }
 
 func (repository *SwipeRepositoryImpl) FindAllSwipeeNotMatch(ctx context.Context, tx *sql.Tx, swipee int) ([]*models.Swipe, error) {
-	sql := `SELECT id, swiper_id, swipee_id, direction, swiped_at FROM swipes WHERE swipee_id = ?`
+	sql := `SELECT id, swiper_id, swipee_id, direction, swiped_at FROM swipes WHERE swipee_id = ?
+		AND swiper_id NOT IN (SELECT id FROM users WHERE shadow_banned_at IS NOT NULL)`
 
 	rows, err := tx.QueryContext(ctx, sql, swipee)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	var swipes []*models.Swipe
 	for rows.Next() {
 		var swipe models.Swipe
 		if err := rows.Scan(
-			swipe.Id,
-			swipe.SwiperID,
-			swipe.SwipeeID,
-			swipe.SwipedAt,
+			&swipe.Id,
+			&swipe.SwiperID,
+			&swipe.SwipeeID,
+			&swipe.Direction,
+			&swipe.SwipedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"sweatsparks/internal/models"
+	"time"
 )
 
 type UserRepository interface {
@@ -12,7 +14,18 @@ type UserRepository interface {
 	FindUserByEmail(ctx context.Context, tx *sql.Tx, email string) (*models.User, error)
 	FindUserByUsername(ctx context.Context, tx *sql.Tx, username string) (*models.User, error)
 	FindUserById(ctx context.Context, tx *sql.Tx, id int) (*models.User, error)
-	FindAllUser(ctx context.Context, tx *sql.Tx) ([]*models.User, error)
+	SearchUsers(ctx context.Context, tx *sql.Tx, query, role string, limit, offset int) ([]*models.User, error)
+	FindUsersByIDs(ctx context.Context, tx *sql.Tx, ids []uint64) ([]*models.User, error)
+	UpdateLastSeen(ctx context.Context, tx *sql.Tx, id uint64, lastSeenAt time.Time) error
+	UpdateRole(ctx context.Context, tx *sql.Tx, id uint64, role string) error
+	VerifyEmail(ctx context.Context, tx *sql.Tx, id uint64, verifiedAt time.Time) error
+	UpdateEmailVerificationSent(ctx context.Context, tx *sql.Tx, id uint64, sentAt time.Time) error
+	SuspendUser(ctx context.Context, tx *sql.Tx, id uint64, reason string, suspendedAt, suspendedUntil time.Time) error
+	UnsuspendUser(ctx context.Context, tx *sql.Tx, id uint64) error
+	BanUser(ctx context.Context, tx *sql.Tx, id uint64, reason string, bannedAt time.Time) error
+	UnbanUser(ctx context.Context, tx *sql.Tx, id uint64) error
+	ShadowBanUser(ctx context.Context, tx *sql.Tx, id uint64, shadowBannedAt time.Time) error
+	UnshadowBanUser(ctx context.Context, tx *sql.Tx, id uint64) error
 }
 
 type UserRepositoryImpl struct {
@@ -22,9 +35,21 @@ func NewUserRepository() UserRepository {
 	return &UserRepositoryImpl{}
 }
 
+const userColumns = "id, email, email_verified_at, email_verification_sent_at, username, password_hash, role, created_at, updated_at, last_seen_at, suspended_at, suspended_until, suspension_reason, banned_at, ban_reason, shadow_banned_at"
+
+func scanUser(rows *sql.Rows) (*models.User, error) {
+	var user models.User
+	err := rows.Scan(&user.Id, &user.Email, &user.EmailVerifiedAt, &user.EmailVerificationSentAt, &user.Username, &user.PasswordHash, &user.Role, &user.CreatedAt, &user.UpdatedAt, &user.LastSeenAt,
+		&user.SuspendedAt, &user.SuspendedUntil, &user.SuspensionReason, &user.BannedAt, &user.BanReason, &user.ShadowBannedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (repository *UserRepositoryImpl) CreateUser(ctx context.Context, tx *sql.Tx, user *models.User) error {
-	SQL := "insert into users(username, email, password_hash, created_at, updated_at) values (?, ?, ?, ?, ?)"
-	response, err := tx.ExecContext(ctx, SQL, user.Username, user.Email, user.PasswordHash, user.CreatedAt, user.UpdatedAt)
+	SQL := "insert into users(username, email, email_verification_sent_at, password_hash, created_at, updated_at) values (?, ?, ?, ?, ?, ?)"
+	response, err := tx.ExecContext(ctx, SQL, user.Username, user.Email, user.EmailVerificationSentAt, user.PasswordHash, user.CreatedAt, user.UpdatedAt)
 	if err != nil {
 		return errors.New("Failed to create a user register, transaction rolled back. Reason: " + err.Error())
 	}
@@ -40,67 +65,98 @@ func (repository *UserRepositoryImpl) CreateUser(ctx context.Context, tx *sql.Tx
 }
 
 func (repository *UserRepositoryImpl) FindUserByEmail(ctx context.Context, tx *sql.Tx, email string) (*models.User, error) {
-	SQL := "select id, email, username, password_hash, created_at, updated_at from users where email = ?"
+	SQL := "select " + userColumns + " from users where email = ?"
 	rows, err := tx.QueryContext(ctx, SQL, email)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var user = models.User{}
 	if rows.Next() {
-		err := rows.Scan(&user.Id, &user.Email, &user.Username, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
-		if err != nil {
-			return nil, err
-		}
-		return &user, nil
+		return scanUser(rows)
 	} else {
 		return nil, errors.New("user is not found")
 	}
 }
 func (repository *UserRepositoryImpl) FindUserByUsername(ctx context.Context, tx *sql.Tx, username string) (*models.User, error) {
-	SQL := "select id, email, username, password_hash, created_at, updated_at from users where username = ?"
+	SQL := "select " + userColumns + " from users where username = ?"
 	rows, err := tx.QueryContext(ctx, SQL, username)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var user = models.User{}
 	if rows.Next() {
-		err := rows.Scan(&user.Id, &user.Email, &user.Username, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
-		if err != nil {
-			return nil, err
-		}
-		return &user, nil
+		return scanUser(rows)
 	} else {
 		return nil, errors.New("user is not found")
 	}
 }
 
 func (repository *UserRepositoryImpl) FindUserById(ctx context.Context, tx *sql.Tx, id int) (*models.User, error) {
-	SQL := "select id, email, username, password_hash, created_at, updated_at from users where id = ?"
+	SQL := "select " + userColumns + " from users where id = ?"
 	rows, err := tx.QueryContext(ctx, SQL, id)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var user = models.User{}
 	if rows.Next() {
-		err := rows.Scan(&user.Id, &user.Email, &user.Username, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
+		return scanUser(rows)
+	} else {
+		return nil, errors.New("user is not found")
+	}
+}
+
+// SearchUsers matches query against username and email. An empty query or
+// role matches every user.
+func (repository *UserRepositoryImpl) SearchUsers(ctx context.Context, tx *sql.Tx, query, role string, limit, offset int) ([]*models.User, error) {
+	SQL := "select " + userColumns + " from users where 1 = 1"
+	var args []interface{}
+	if query != "" {
+		SQL += " and (username like ? or email like ?)"
+		pattern := "%" + query + "%"
+		args = append(args, pattern, pattern)
+	}
+	if role != "" {
+		SQL += " and role = ?"
+		args = append(args, role)
+	}
+	SQL += " order by id limit ? offset ?"
+	args = append(args, limit, offset)
+
+	rows, err := tx.QueryContext(ctx, SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
 		if err != nil {
 			return nil, err
 		}
-		return &user, nil
-	} else {
-		return nil, errors.New("user is not found")
+		users = append(users, user)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
 }
 
-func (repository *UserRepositoryImpl) FindAllUser(ctx context.Context, tx *sql.Tx) ([]*models.User, error) {
-	SQL := "select id, email, username, password_hash, created_at, updated_at from users"
-	rows, err := tx.QueryContext(ctx, SQL)
+func (repository *UserRepositoryImpl) FindUsersByIDs(ctx context.Context, tx *sql.Tx, ids []uint64) ([]*models.User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	SQL := "select " + userColumns + " from users where id in (?" + strings.Repeat(",?", len(ids)-1) + ")"
+	rows, err := tx.QueryContext(ctx, SQL, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -108,14 +164,104 @@ func (repository *UserRepositoryImpl) FindAllUser(ctx context.Context, tx *sql.T
 
 	var users []*models.User
 	for rows.Next() {
-		var user models.User
-		if err := rows.Scan(&user.Id, &user.Email, &user.Username, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		user, err := scanUser(rows)
+		if err != nil {
 			return nil, err
 		}
-		users = append(users, &user)
+		users = append(users, user)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 	return users, nil
 }
+
+func (repository *UserRepositoryImpl) UpdateLastSeen(ctx context.Context, tx *sql.Tx, id uint64, lastSeenAt time.Time) error {
+	SQL := "update users set last_seen_at = ? where id = ?"
+	_, err := tx.ExecContext(ctx, SQL, lastSeenAt, id)
+	if err != nil {
+		return errors.New("Failed to update last seen, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *UserRepositoryImpl) UpdateRole(ctx context.Context, tx *sql.Tx, id uint64, role string) error {
+	SQL := "update users set role = ?, updated_at = ? where id = ?"
+	_, err := tx.ExecContext(ctx, SQL, role, time.Now(), id)
+	if err != nil {
+		return errors.New("Failed to update role, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *UserRepositoryImpl) VerifyEmail(ctx context.Context, tx *sql.Tx, id uint64, verifiedAt time.Time) error {
+	SQL := "update users set email_verified_at = ?, updated_at = ? where id = ? and email_verified_at is null"
+	_, err := tx.ExecContext(ctx, SQL, verifiedAt, verifiedAt, id)
+	if err != nil {
+		return errors.New("Failed to verify email, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *UserRepositoryImpl) UpdateEmailVerificationSent(ctx context.Context, tx *sql.Tx, id uint64, sentAt time.Time) error {
+	SQL := "update users set email_verification_sent_at = ? where id = ?"
+	_, err := tx.ExecContext(ctx, SQL, sentAt, id)
+	if err != nil {
+		return errors.New("Failed to update email verification, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *UserRepositoryImpl) SuspendUser(ctx context.Context, tx *sql.Tx, id uint64, reason string, suspendedAt, suspendedUntil time.Time) error {
+	SQL := "update users set suspended_at = ?, suspended_until = ?, suspension_reason = ? where id = ?"
+	_, err := tx.ExecContext(ctx, SQL, suspendedAt, suspendedUntil, reason, id)
+	if err != nil {
+		return errors.New("Failed to suspend user, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *UserRepositoryImpl) UnsuspendUser(ctx context.Context, tx *sql.Tx, id uint64) error {
+	SQL := "update users set suspended_at = null, suspended_until = null, suspension_reason = null where id = ?"
+	_, err := tx.ExecContext(ctx, SQL, id)
+	if err != nil {
+		return errors.New("Failed to lift suspension, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *UserRepositoryImpl) BanUser(ctx context.Context, tx *sql.Tx, id uint64, reason string, bannedAt time.Time) error {
+	SQL := "update users set banned_at = ?, ban_reason = ? where id = ?"
+	_, err := tx.ExecContext(ctx, SQL, bannedAt, reason, id)
+	if err != nil {
+		return errors.New("Failed to ban user, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *UserRepositoryImpl) UnbanUser(ctx context.Context, tx *sql.Tx, id uint64) error {
+	SQL := "update users set banned_at = null, ban_reason = null where id = ?"
+	_, err := tx.ExecContext(ctx, SQL, id)
+	if err != nil {
+		return errors.New("Failed to lift ban, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *UserRepositoryImpl) ShadowBanUser(ctx context.Context, tx *sql.Tx, id uint64, shadowBannedAt time.Time) error {
+	SQL := "update users set shadow_banned_at = ? where id = ?"
+	_, err := tx.ExecContext(ctx, SQL, shadowBannedAt, id)
+	if err != nil {
+		return errors.New("Failed to shadow-ban user, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
+
+func (repository *UserRepositoryImpl) UnshadowBanUser(ctx context.Context, tx *sql.Tx, id uint64) error {
+	SQL := "update users set shadow_banned_at = null where id = ?"
+	_, err := tx.ExecContext(ctx, SQL, id)
+	if err != nil {
+		return errors.New("Failed to lift shadow-ban, transaction rolled back. Reason: " + err.Error())
+	}
+	return nil
+}
@@ -1,40 +1,86 @@
 package routes
 
 import (
-	"database/sql"
 	"net/http"
 	"sweatsparks/internal/factory"
 	"sweatsparks/internal/middleware"
-	websockets "sweatsparks/internal/websocket"
+	"sweatsparks/internal/models"
 
 	"github.com/gorilla/mux"
 )
 
-func RegisterRoutes(db *sql.DB, router *mux.Router, hub *websockets.Hub, provider *factory.Provider) {
+func RegisterRoutes(router *mux.Router, provider *factory.Provider) {
+	router.HandleFunc("/.well-known/jwks.json", provider.JWKSProvider.GetJWKS).Methods("GET")
+
 	router.HandleFunc("/api/auth/register", provider.UserProvider.Register).Methods("POST")
 	router.HandleFunc("/api/auth/login", provider.UserProvider.Login).Methods("POST")
+	router.HandleFunc("/api/auth/refresh", provider.UserProvider.Refresh).Methods("POST")
+	router.HandleFunc("/api/auth/verify-email", provider.UserProvider.VerifyEmail).Methods("POST")
+
+	// Partners reach only the routes in this subrouter, each gated by the
+	// scope its key must hold. Users reach them with their token as usual.
+	partner := router.PathPrefix("/api").Subrouter()
+	partner.Use(middleware.AuthMiddleware(provider.TokenValidator, provider.APIKeyValidator))
+	partner.Handle("/profiles", middleware.RequireScope(models.ScopeProfilesRead)(http.HandlerFunc(provider.ProfileProvider.GetAllProfile))).Methods("GET")
+	partner.Handle("/profiles/{userID}", middleware.RequireScope(models.ScopeProfilesRead)(http.HandlerFunc(provider.ProfileProvider.GetDetailProfile))).Methods("GET")
 
 	protected := router.PathPrefix("/api").Subrouter()
-	protected.Use(middleware.AuthMiddleware)
-	protected.HandleFunc("/users", provider.UserProvider.GetAllUsers).Methods("GET")
+	protected.Use(middleware.AuthMiddleware(provider.TokenValidator, nil))
+
+	protected.HandleFunc("/auth/logout", provider.UserProvider.Logout).Methods("POST")
+	protected.HandleFunc("/auth/verify-email/resend", provider.UserProvider.ResendVerificationEmail).Methods("POST")
+	protected.HandleFunc("/auth/sessions", provider.SessionProvider.GetSessions).Methods("GET")
+	protected.HandleFunc("/auth/sessions", provider.SessionProvider.RevokeOtherSessions).Methods("DELETE")
+	protected.HandleFunc("/auth/sessions/{sessionID:[0-9]+}", provider.SessionProvider.RevokeSession).Methods("DELETE")
 
-	protected.HandleFunc("/matches", provider.MatchProvider.CreateMatch).Methods("POST")
 	protected.HandleFunc("/matches", provider.MatchProvider.GetAllMatchUser).Methods("GET")
 	protected.HandleFunc("/matches/{userID}", provider.MatchProvider.GetDetailMatchUser).Methods("GET")
+	protected.HandleFunc("/matches/{matchID}/export", provider.ExportProvider.ExportConversation).Methods("GET")
+	protected.HandleFunc("/matches/{matchID}/disappearing", provider.MessageProvider.UpdateDisappearing).Methods("PATCH")
 
-	protected.HandleFunc("/profiles", provider.ProfileProvider.GetAllProfile).Methods("GET")
 	protected.HandleFunc("/profiles", provider.ProfileProvider.CreateProfile).Methods("POST")
-	protected.HandleFunc("/profiles/{userID}", provider.ProfileProvider.GetDetailProfile).Methods("GET")
 	protected.HandleFunc("/profiles/{userID}", provider.ProfileProvider.UpdateProfile).Methods("PATCH")
 
+	protected.HandleFunc("/messages/search", provider.MessageProvider.SearchMessages).Methods("GET")
 	protected.HandleFunc("/messages/{matchID}", provider.MessageProvider.GetMessageByMatchID).Methods("GET")
+	protected.HandleFunc("/messages/{matchID}/{messageID:[0-9]+}", provider.MessageProvider.EditMessage).Methods("PATCH")
+	protected.HandleFunc("/messages/{matchID}/{messageID:[0-9]+}", provider.MessageProvider.DeleteMessage).Methods("DELETE")
+	protected.HandleFunc("/messages/{matchID}/{messageID:[0-9]+}/unsend", provider.MessageProvider.UnsendMessage).Methods("POST")
+	protected.HandleFunc("/messages/{matchID}/{messageID:[0-9]+}/edits", provider.MessageProvider.GetMessageEdits).Methods("GET")
+	protected.HandleFunc("/messages/{matchID}/{messageID:[0-9]+}/reactions", provider.ReactionProvider.AddReaction).Methods("PUT")
+	protected.HandleFunc("/messages/{matchID}/{messageID:[0-9]+}/reactions", provider.ReactionProvider.RemoveReaction).Methods("DELETE")
+	protected.HandleFunc("/messages/{matchID}/attachments", provider.AttachmentProvider.UploadAttachment).Methods("POST")
+	protected.HandleFunc("/messages/{matchID}/attachments/{attachmentID}", provider.AttachmentProvider.DownloadAttachment).Methods("GET")
 
 	protected.HandleFunc("/swipes", provider.SwipeProvider.CreateSwipe).Methods("POST")
 	protected.HandleFunc("/swipes/{swiperID}", provider.SwipeProvider.GetSwipeAll).Methods("GET")
 	protected.HandleFunc("/swipes/{swiperID}/swipee/{swipeeID}", provider.SwipeProvider.GetSwipeDetail).Methods("GET")
 
-	wsHandler := websockets.NewHandler(hub, db)
-	router.HandleFunc("/ws/{userID}/room/{roomID}", func(w http.ResponseWriter, r *http.Request) {
-		wsHandler.ServeWs(w, r)
-	})
+	protected.HandleFunc("/blocks", provider.BlockProvider.GetBlockedUsers).Methods("GET")
+	protected.HandleFunc("/blocks/{userID}", provider.BlockProvider.BlockUser).Methods("POST")
+	protected.HandleFunc("/blocks/{userID}", provider.BlockProvider.UnblockUser).Methods("DELETE")
+
+	protected.HandleFunc("/reports", provider.ReportProvider.CreateReport).Methods("POST")
+
+	protected.HandleFunc("/presence", provider.PresenceProvider.GetPresence).Methods("GET")
+
+	admin := protected.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.RequireRole(models.RoleModerator, models.RoleAdmin))
+	admin.HandleFunc("/users", provider.UserProvider.GetAllUsers).Methods("GET")
+	admin.HandleFunc("/users/{userID:[0-9]+}/suspension", provider.UserProvider.SuspendUser).Methods("POST")
+	admin.HandleFunc("/users/{userID:[0-9]+}/suspension", provider.UserProvider.UnsuspendUser).Methods("DELETE")
+	admin.HandleFunc("/users/{userID:[0-9]+}/ban", provider.UserProvider.BanUser).Methods("POST")
+	admin.HandleFunc("/users/{userID:[0-9]+}/ban", provider.UserProvider.UnbanUser).Methods("DELETE")
+	admin.HandleFunc("/users/{userID:[0-9]+}/shadow-ban", provider.UserProvider.ShadowBanUser).Methods("POST")
+	admin.HandleFunc("/users/{userID:[0-9]+}/shadow-ban", provider.UserProvider.UnshadowBanUser).Methods("DELETE")
+	admin.Handle("/users/{userID:[0-9]+}/role", middleware.RequireRole(models.RoleAdmin)(http.HandlerFunc(provider.UserProvider.UpdateUserRole))).Methods("PATCH")
+	admin.HandleFunc("/profiles/{userID:[0-9]+}", provider.ProfileProvider.GetModerationProfile).Methods("GET")
+	admin.HandleFunc("/reports", provider.ReportProvider.GetReports).Methods("GET")
+	admin.HandleFunc("/reports/{reportID:[0-9]+}", provider.ReportProvider.GetReport).Methods("GET")
+	admin.HandleFunc("/reports/{reportID:[0-9]+}", provider.ReportProvider.ReviewReport).Methods("PATCH")
+	admin.Handle("/api-keys", middleware.RequireRole(models.RoleAdmin)(http.HandlerFunc(provider.APIKeyProvider.GetAPIKeys))).Methods("GET")
+	admin.Handle("/api-keys", middleware.RequireRole(models.RoleAdmin)(http.HandlerFunc(provider.APIKeyProvider.CreateAPIKey))).Methods("POST")
+	admin.Handle("/api-keys/{keyID:[0-9]+}", middleware.RequireRole(models.RoleAdmin)(http.HandlerFunc(provider.APIKeyProvider.RevokeAPIKey))).Methods("DELETE")
+
+	router.HandleFunc("/ws", provider.WebsocketProvider.ServeWs).Methods("GET")
 }
@@ -0,0 +1,89 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// snippetRadius is how many bytes of context are kept on each side of the
+// first matching term.
+const snippetRadius = 60
+
+// Highlight returns an HTML snippet of content around the first term found,
+// with every occurrence of the terms wrapped in <mark>. The rest of the text
+// is escaped.
+func Highlight(content string, terms []string) string {
+	lower := strings.ToLower(content)
+	// Lower-casing can change byte lengths; fall back to a plain prefix
+	// rather than cut runes in half.
+	if len(lower) != len(content) {
+		return html.EscapeString(truncate(content, 2*snippetRadius))
+	}
+
+	first := -1
+	for _, term := range terms {
+		if i := strings.Index(lower, term); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	if first < 0 {
+		return html.EscapeString(truncate(content, 2*snippetRadius))
+	}
+
+	start, end := first-snippetRadius, first+snippetRadius
+	if start < 0 {
+		start = 0
+	}
+	if end > len(content) {
+		end = len(content)
+	}
+	for start > 0 && !utf8.RuneStart(content[start]) {
+		start--
+	}
+	for end < len(content) && !utf8.RuneStart(content[end]) {
+		end++
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		term := longestTermAt(lower[i:], terms)
+		if term == "" {
+			_, size := utf8.DecodeRuneInString(content[i:])
+			b.WriteString(html.EscapeString(content[i : i+size]))
+			i += size
+			continue
+		}
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(content[i : i+len(term)]))
+		b.WriteString("</mark>")
+		i += len(term)
+	}
+	if end < len(content) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+func longestTermAt(s string, terms []string) string {
+	longest := ""
+	for _, term := range terms {
+		if len(term) > len(longest) && strings.HasPrefix(s, term) {
+			longest = term
+		}
+	}
+	return longest
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,58 @@
+// Package search finds chat messages by their content.
+package search
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Document is a message as the index sees it.
+type Document struct {
+	MessageID uint64
+	MatchID   uint64
+	SenderID  uint64
+	Content   string
+	SentAt    time.Time
+	// ExpireAfter is set on messages that start expiring once they are
+	// read. ExpiresAt is when a disappearing message stops being found; it is
+	// zero until its deadline is known.
+	ExpireAfter time.Duration
+	ExpiresAt   time.Time
+}
+
+// Query searches the matches in MatchIDs on behalf of ViewerID, skipping
+// messages the viewer deleted for themselves, expired messages and messages
+// from shadow-banned users other than the viewer. Results are newest first.
+type Query struct {
+	ViewerID uint64
+	MatchIDs []uint64
+	Text     string
+	Offset   int
+	Limit    int
+}
+
+// Index is implemented by every search backend. Backends that read straight
+// from the messages and users tables may treat every method but Search as a
+// no-op.
+type Index interface {
+	Index(ctx context.Context, doc Document) error
+	Remove(ctx context.Context, messageIDs ...uint64) error
+	Hide(ctx context.Context, messageID, userID uint64) error
+	// MarkRead starts the expiry of the messages of a match up to upToID that
+	// expire once read by readerID, as MarkMessagesRead does in the database.
+	MarkRead(ctx context.Context, matchID, readerID, upToID uint64, at time.Time) error
+	// ShadowBan keeps a user's messages out of everyone else's results, or
+	// lets them back in.
+	ShadowBan(ctx context.Context, userID uint64, banned bool) error
+	Search(ctx context.Context, query Query) ([]Document, error)
+}
+
+// Terms splits a search string into lower-cased words, dropping punctuation
+// so it can't be read as query syntax by a backend.
+func Terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
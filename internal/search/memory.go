@@ -0,0 +1,146 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryIndex keeps documents in process. It is meant for tests and single
+// node development setups.
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[uint64]Document
+	hidden   map[uint64]map[uint64]bool
+	shadowed map[uint64]bool
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     make(map[uint64]Document),
+		hidden:   make(map[uint64]map[uint64]bool),
+		shadowed: make(map[uint64]bool),
+	}
+}
+
+func (m *MemoryIndex) Index(ctx context.Context, doc Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.docs[doc.MessageID] = doc
+	return nil
+}
+
+func (m *MemoryIndex) Remove(ctx context.Context, messageIDs ...uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range messageIDs {
+		delete(m.docs, id)
+		delete(m.hidden, id)
+	}
+	return nil
+}
+
+func (m *MemoryIndex) Hide(ctx context.Context, messageID, userID uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.hidden[messageID]; !ok {
+		m.hidden[messageID] = make(map[uint64]bool)
+	}
+	m.hidden[messageID][userID] = true
+	return nil
+}
+
+// MarkRead only sets deadlines that are not known yet, as a message is only
+// read once.
+func (m *MemoryIndex) MarkRead(ctx context.Context, matchID, readerID, upToID uint64, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, doc := range m.docs {
+		if doc.MatchID == matchID && doc.SenderID != readerID && id <= upToID && doc.ExpireAfter > 0 && doc.ExpiresAt.IsZero() {
+			doc.ExpiresAt = at.Add(doc.ExpireAfter)
+			m.docs[id] = doc
+		}
+	}
+	return nil
+}
+
+func (m *MemoryIndex) ShadowBan(ctx context.Context, userID uint64, banned bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if banned {
+		m.shadowed[userID] = true
+	} else {
+		delete(m.shadowed, userID)
+	}
+	return nil
+}
+
+// Search returns documents containing a word starting with every query term,
+// like a MySQL boolean mode search for +term*, with the same filters as
+// MySQLIndex.
+func (m *MemoryIndex) Search(ctx context.Context, query Query) ([]Document, error) {
+	terms := Terms(query.Text)
+	if len(terms) == 0 || len(query.MatchIDs) == 0 {
+		return nil, nil
+	}
+
+	matches := make(map[uint64]bool)
+	for _, id := range query.MatchIDs {
+		matches[id] = true
+	}
+
+	now := time.Now()
+	m.mu.RLock()
+	var found []Document
+	for _, doc := range m.docs {
+		if !matches[doc.MatchID] || m.hidden[doc.MessageID][query.ViewerID] {
+			continue
+		}
+		if !doc.ExpiresAt.IsZero() && !doc.ExpiresAt.After(now) {
+			continue
+		}
+		if doc.SenderID != query.ViewerID && m.shadowed[doc.SenderID] {
+			continue
+		}
+		if containsAll(Terms(doc.Content), terms) {
+			found = append(found, doc)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].MessageID > found[j].MessageID
+	})
+
+	if query.Offset >= len(found) {
+		return nil, nil
+	}
+	found = found[query.Offset:]
+	if query.Limit > 0 && len(found) > query.Limit {
+		found = found[:query.Limit]
+	}
+	return found, nil
+}
+
+func containsAll(words, terms []string) bool {
+	for _, term := range terms {
+		ok := false
+		for _, word := range words {
+			if strings.HasPrefix(word, term) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
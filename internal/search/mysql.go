@@ -0,0 +1,82 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// MySQLIndex searches the messages table through its FULLTEXT index on
+// content. MySQL keeps that index up to date by itself, and the hidden,
+// expiry and shadow-ban filters read their tables directly, so only Search has
+// anything to do.
+type MySQLIndex struct {
+	db *sql.DB
+}
+
+func NewMySQLIndex(db *sql.DB) *MySQLIndex {
+	return &MySQLIndex{db: db}
+}
+
+func (m *MySQLIndex) Index(ctx context.Context, doc Document) error {
+	return nil
+}
+
+func (m *MySQLIndex) Remove(ctx context.Context, messageIDs ...uint64) error {
+	return nil
+}
+
+func (m *MySQLIndex) Hide(ctx context.Context, messageID, userID uint64) error {
+	return nil
+}
+
+func (m *MySQLIndex) MarkRead(ctx context.Context, matchID, readerID, upToID uint64, at time.Time) error {
+	return nil
+}
+
+func (m *MySQLIndex) ShadowBan(ctx context.Context, userID uint64, banned bool) error {
+	return nil
+}
+
+func (m *MySQLIndex) Search(ctx context.Context, query Query) ([]Document, error) {
+	terms := Terms(query.Text)
+	if len(terms) == 0 || len(query.MatchIDs) == 0 {
+		return nil, nil
+	}
+
+	against := make([]string, len(terms))
+	for i, term := range terms {
+		against[i] = "+" + term + "*"
+	}
+
+	args := []interface{}{}
+	for _, id := range query.MatchIDs {
+		args = append(args, id)
+	}
+	args = append(args, strings.Join(against, " "), time.Now(), query.ViewerID, query.ViewerID, query.Limit, query.Offset)
+
+	SQL := `SELECT id, match_id, sender_id, content, sent_at FROM messages
+		WHERE match_id IN (?` + strings.Repeat(",?", len(query.MatchIDs)-1) + `)
+		AND MATCH (content) AGAINST (? IN BOOLEAN MODE)
+		AND type = 'text' AND deleted_at IS NULL
+		AND (expires_at IS NULL OR expires_at > ?)
+		AND id NOT IN (SELECT message_id FROM message_hidden WHERE user_id = ?)
+		AND (sender_id = ? OR sender_id NOT IN (SELECT id FROM users WHERE shadow_banned_at IS NOT NULL))
+		ORDER BY id DESC LIMIT ? OFFSET ?`
+	rows, err := m.db.QueryContext(ctx, SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []Document
+	for rows.Next() {
+		var doc Document
+		if err := rows.Scan(&doc.MessageID, &doc.MatchID, &doc.SenderID, &doc.Content, &doc.SentAt); err != nil {
+			return nil, err
+		}
+		found = append(found, doc)
+	}
+	return found, rows.Err()
+}
@@ -0,0 +1,90 @@
+package search
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryIndexSearchesOnlyVisibleMatches(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemoryIndex()
+	now := time.Now()
+
+	require.NoError(t, index.Index(ctx, Document{MessageID: 1, MatchID: 10, SenderID: 1, Content: "Leg day tomorrow?", SentAt: now}))
+	require.NoError(t, index.Index(ctx, Document{MessageID: 2, MatchID: 10, SenderID: 2, Content: "Legs again, really", SentAt: now}))
+	require.NoError(t, index.Index(ctx, Document{MessageID: 3, MatchID: 20, SenderID: 3, Content: "leg press PR", SentAt: now}))
+	require.NoError(t, index.Index(ctx, Document{MessageID: 4, MatchID: 10, SenderID: 1, Content: "see you at the gym", SentAt: now}))
+
+	found, err := index.Search(ctx, Query{ViewerID: 1, MatchIDs: []uint64{10}, Text: "LEG", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	require.Equal(t, uint64(2), found[0].MessageID)
+	require.Equal(t, uint64(1), found[1].MessageID)
+
+	require.NoError(t, index.Hide(ctx, 2, 1))
+	require.NoError(t, index.Remove(ctx, 1))
+
+	found, err = index.Search(ctx, Query{ViewerID: 1, MatchIDs: []uint64{10}, Text: "leg", Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, found)
+
+	found, err = index.Search(ctx, Query{ViewerID: 2, MatchIDs: []uint64{10}, Text: "leg", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+}
+
+func TestMemoryIndexSkipsShadowBannedSenders(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemoryIndex()
+	now := time.Now()
+
+	require.NoError(t, index.Index(ctx, Document{MessageID: 1, MatchID: 10, SenderID: 1, Content: "squat session", SentAt: now}))
+	require.NoError(t, index.Index(ctx, Document{MessageID: 2, MatchID: 10, SenderID: 2, Content: "squat PR", SentAt: now}))
+	require.NoError(t, index.ShadowBan(ctx, 2, true))
+
+	found, err := index.Search(ctx, Query{ViewerID: 1, MatchIDs: []uint64{10}, Text: "squat", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, uint64(1), found[0].MessageID)
+
+	// The shadow-banned user still finds their own messages.
+	found, err = index.Search(ctx, Query{ViewerID: 2, MatchIDs: []uint64{10}, Text: "squat", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+
+	require.NoError(t, index.ShadowBan(ctx, 2, false))
+	found, err = index.Search(ctx, Query{ViewerID: 1, MatchIDs: []uint64{10}, Text: "squat", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+}
+
+func TestMemoryIndexSkipsExpiredMessages(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemoryIndex()
+	now := time.Now()
+
+	require.NoError(t, index.Index(ctx, Document{MessageID: 1, MatchID: 10, SenderID: 1, Content: "run club", SentAt: now, ExpiresAt: now.Add(-time.Second)}))
+	require.NoError(t, index.Index(ctx, Document{MessageID: 2, MatchID: 10, SenderID: 1, Content: "run later", SentAt: now, ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, index.Index(ctx, Document{MessageID: 3, MatchID: 10, SenderID: 1, Content: "run once read", SentAt: now, ExpireAfter: time.Minute}))
+
+	found, err := index.Search(ctx, Query{ViewerID: 2, MatchIDs: []uint64{10}, Text: "run", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	require.Equal(t, uint64(3), found[0].MessageID)
+	require.Equal(t, uint64(2), found[1].MessageID)
+
+	// Reading starts the clock; a read long enough ago has expired.
+	require.NoError(t, index.MarkRead(ctx, 10, 2, 3, now.Add(-time.Hour)))
+	found, err = index.Search(ctx, Query{ViewerID: 2, MatchIDs: []uint64{10}, Text: "run", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, uint64(2), found[0].MessageID)
+}
+
+func TestHighlightMarksTermsAndEscapes(t *testing.T) {
+	snippet := Highlight("Meet <b>me</b> at the gym, gym time!", Terms("GYM"))
+	require.Equal(t, "Meet &lt;b&gt;me&lt;/b&gt; at the <mark>gym</mark>, <mark>gym</mark> time!", snippet)
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/middleware"
+	"sweatsparks/internal/models"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/repositories"
+	"sweatsparks/pkg/helpers"
+	"sweatsparks/pkg/token"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator"
+)
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, req *params.APIKeyRequest) (*params.APIKeyCreatedResponse, *response.CustomError)
+	GetAPIKeys(ctx context.Context, partner string) ([]*params.APIKeyResponse, *response.CustomError)
+	RevokeAPIKey(ctx context.Context, keyID uint64) (*params.APIKeyResponse, *response.CustomError)
+	ValidateAPIKey(ctx context.Context, apiKey string) (*params.AuthenticatedAPIKey, *response.CustomError)
+}
+
+// rateWindow is the window API key rate limits are counted over.
+const rateWindow = time.Minute
+
+// apiKeyTouchInterval is how stale last_used_at may get before a request
+// with the key writes a new one.
+const apiKeyTouchInterval = time.Minute
+
+type APIKeyServiceImpl struct {
+	MySqlDB          *sql.DB
+	APIKeyRepository repositories.APIKeyRepository
+
+	// windows counts the requests each key made in its current window. The
+	// count is per instance, so behind N instances a key gets up to N times
+	// its limit.
+	mu      sync.Mutex
+	windows map[uint64]*rateCounter
+}
+
+type rateCounter struct {
+	start time.Time
+	count int
+}
+
+func NewAPIKeyService(db *sql.DB, apiKeyRepository repositories.APIKeyRepository) APIKeyService {
+	return &APIKeyServiceImpl{
+		MySqlDB:          db,
+		APIKeyRepository: apiKeyRepository,
+		windows:          make(map[uint64]*rateCounter),
+	}
+}
+
+// CreateAPIKey issues a key to a partner. The key is in the response and
+// nowhere else; losing it means revoking it and issuing another.
+func (service *APIKeyServiceImpl) CreateAPIKey(ctx context.Context, req *params.APIKeyRequest) (*params.APIKeyCreatedResponse, *response.CustomError) {
+	if custErr := middleware.AuthorizeUser(ctx, req.CreatedBy); custErr != nil {
+		return nil, custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
+	apiKey, prefix, keyHash, err := token.NewAPIKey()
+	if err != nil {
+		log.Printf("error generating API key: %v", err)
+		return nil, response.GeneralError("Failed generating API key")
+	}
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	var key = new(models.APIKey)
+	key.Partner = req.Partner
+	key.Prefix = prefix
+	key.KeyHash = keyHash
+	key.Scopes = req.Scopes
+	key.RateLimit = req.RateLimit
+	key.CreatedBy = req.CreatedBy
+	key.CreatedAt = time.Now()
+
+	err = service.APIKeyRepository.CreateAPIKey(ctx, tx, key)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	return &params.APIKeyCreatedResponse{
+		APIKeyResponse: *toAPIKeyResponse(key),
+		Key:            apiKey,
+	}, nil
+}
+
+func (service *APIKeyServiceImpl) GetAPIKeys(ctx context.Context, partner string) ([]*params.APIKeyResponse, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	keys, err := service.APIKeyRepository.FindAPIKeys(ctx, tx, partner)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	result := make([]*params.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, toAPIKeyResponse(key))
+	}
+	return result, nil
+}
+
+func (service *APIKeyServiceImpl) RevokeAPIKey(ctx context.Context, keyID uint64) (*params.APIKeyResponse, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	key, err := service.APIKeyRepository.FindAPIKeyByID(ctx, tx, keyID)
+	if err != nil {
+		return nil, response.NotFoundError("API key not found")
+	}
+
+	if !key.RevokedAt.Valid {
+		now := time.Now()
+		err = service.APIKeyRepository.RevokeAPIKey(ctx, tx, key.Id, now)
+		if err != nil {
+			return nil, response.GeneralError(err.Error())
+		}
+		key.RevokedAt = sql.NullTime{Time: now, Valid: true}
+	}
+
+	return toAPIKeyResponse(key), nil
+}
+
+// ValidateAPIKey resolves the partner behind a key and counts the request
+// against the key's rate limit.
+func (service *APIKeyServiceImpl) ValidateAPIKey(ctx context.Context, apiKey string) (*params.AuthenticatedAPIKey, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	key, err := service.APIKeyRepository.FindAPIKeyByHash(ctx, tx, token.HashAPIKey(apiKey))
+	if err != nil || key.RevokedAt.Valid {
+		return nil, response.UnauthorizedError("Invalid API key")
+	}
+
+	now := time.Now()
+	if !service.allow(key, now) {
+		return nil, response.TooManyRequestsError("API key rate limit exceeded")
+	}
+
+	if !key.LastUsedAt.Valid || now.Sub(key.LastUsedAt.Time) > apiKeyTouchInterval {
+		err = service.APIKeyRepository.TouchAPIKey(ctx, tx, key.Id, now)
+		if err != nil {
+			return nil, response.GeneralError(err.Error())
+		}
+	}
+
+	return &params.AuthenticatedAPIKey{
+		KeyID:   key.Id,
+		Partner: key.Partner,
+		Scopes:  key.Scopes,
+	}, nil
+}
+
+// allow counts a request against the key's fixed window and reports whether
+// it is within the limit.
+func (service *APIKeyServiceImpl) allow(key *models.APIKey, now time.Time) bool {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	window, ok := service.windows[key.Id]
+	if !ok || now.Sub(window.start) >= rateWindow {
+		window = &rateCounter{start: now}
+		service.windows[key.Id] = window
+	}
+	if window.count >= key.RateLimit {
+		return false
+	}
+	window.count++
+	return true
+}
+
+func toAPIKeyResponse(key *models.APIKey) *params.APIKeyResponse {
+	return &params.APIKeyResponse{
+		ID:         key.Id,
+		Partner:    key.Partner,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		RateLimit:  key.RateLimit,
+		CreatedBy:  key.CreatedBy,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: nullTimePtr(key.LastUsedAt),
+		RevokedAt:  nullTimePtr(key.RevokedAt),
+	}
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/middleware"
+	"sweatsparks/internal/models"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/repositories"
+	"sweatsparks/pkg/encryption"
+	"sweatsparks/pkg/helpers"
+	"sweatsparks/pkg/storage"
+	"time"
+
+	"github.com/go-playground/validator"
+)
+
+const (
+	AttachmentKindImage = "image"
+	AttachmentKindVoice = "voice"
+)
+
+type attachmentType struct {
+	Kind        string
+	ContentType string
+}
+
+// attachmentTypes maps the sniffed MIME type to what we accept and store.
+// Browsers and phones record voice notes in WebM, Ogg or MP4 containers, which
+// http.DetectContentType reports as video or application types.
+var attachmentTypes = map[string]attachmentType{
+	"image/jpeg":      {AttachmentKindImage, "image/jpeg"},
+	"image/png":       {AttachmentKindImage, "image/png"},
+	"image/gif":       {AttachmentKindImage, "image/gif"},
+	"image/webp":      {AttachmentKindImage, "image/webp"},
+	"audio/mpeg":      {AttachmentKindVoice, "audio/mpeg"},
+	"audio/wave":      {AttachmentKindVoice, "audio/wav"},
+	"audio/aiff":      {AttachmentKindVoice, "audio/aiff"},
+	"application/ogg": {AttachmentKindVoice, "audio/ogg"},
+	"video/webm":      {AttachmentKindVoice, "audio/webm"},
+	"video/mp4":       {AttachmentKindVoice, "audio/mp4"},
+}
+
+type AttachmentService interface {
+	UploadAttachment(ctx context.Context, req *params.AttachmentUploadRequest) (*params.AttachmentResponse, *response.CustomError)
+	GetAttachment(ctx context.Context, matchID, attachmentID, userID int) (*params.AttachmentDownloadResponse, *response.CustomError)
+}
+
+type AttachmentServiceImpl struct {
+	MySqlDB              *sql.DB
+	AttachmentRepository repositories.AttachmentRepository
+	MatchRepository      repositories.MatchRepository
+	Storage              storage.Storage
+	MaxBytes             int64
+}
+
+func NewAttachmentService(db *sql.DB, attachmentRepository repositories.AttachmentRepository, matchRepository repositories.MatchRepository, store storage.Storage, maxBytes int64) AttachmentService {
+	return &AttachmentServiceImpl{
+		MySqlDB:              db,
+		AttachmentRepository: attachmentRepository,
+		MatchRepository:      matchRepository,
+		Storage:              store,
+		MaxBytes:             maxBytes,
+	}
+}
+
+func (service *AttachmentServiceImpl) UploadAttachment(ctx context.Context, req *params.AttachmentUploadRequest) (*params.AttachmentResponse, *response.CustomError) {
+	if custErr := middleware.AuthorizeUser(ctx, req.UploaderID); custErr != nil {
+		return nil, custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
+	if req.Size > service.MaxBytes {
+		return nil, response.BadRequestErrorWithAdditionalInfo(fmt.Sprintf("Attachment exceeds %d bytes.", service.MaxBytes))
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(req.File, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, response.BadRequestErrorWithAdditionalInfo("Failed reading attachment.")
+	}
+	head = head[:n]
+
+	fileType, ok := attachmentTypes[sniffContentType(head)]
+	if !ok {
+		return nil, response.BadRequestErrorWithAdditionalInfo("Unsupported attachment type.")
+	}
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	match, err := service.MatchRepository.FindMatchByID(ctx, tx, req.MatchID)
+	if err != nil || !match.HasMember(req.UploaderID) {
+		return nil, response.NotFoundError("Match not found.")
+	}
+
+	name, err := encryption.RandomHex(16)
+	if err != nil {
+		log.Printf("error generating attachment key for match %d: %v", match.Id, err)
+		return nil, response.GeneralError("Failed storing attachment")
+	}
+	key := fmt.Sprintf("matches/%d/%s", match.Id, name)
+
+	// Read one byte past the limit so a lying Content-Length is still caught.
+	body := io.LimitReader(io.MultiReader(bytes.NewReader(head), req.File), service.MaxBytes+1)
+	size, err := service.Storage.Save(ctx, key, body)
+	if err != nil {
+		log.Printf("error storing attachment %s: %v", key, err)
+		return nil, response.GeneralError("Failed storing attachment")
+	}
+	if size > service.MaxBytes {
+		service.Storage.Delete(ctx, key)
+		return nil, response.BadRequestErrorWithAdditionalInfo(fmt.Sprintf("Attachment exceeds %d bytes.", service.MaxBytes))
+	}
+
+	var attachment = new(models.Attachment)
+	attachment.MatchID = match.Id
+	attachment.UploaderID = req.UploaderID
+	attachment.StorageKey = key
+	attachment.FileName = filepath.Base(req.FileName)
+	attachment.ContentType = fileType.ContentType
+	attachment.Size = size
+	attachment.CreatedAt = time.Now()
+
+	err = service.AttachmentRepository.CreateAttachment(ctx, tx, attachment)
+	if err != nil {
+		service.Storage.Delete(ctx, key)
+		return nil, response.GeneralError(err.Error())
+	}
+
+	return toAttachmentResponse(attachment), nil
+}
+
+func (service *AttachmentServiceImpl) GetAttachment(ctx context.Context, matchID, attachmentID, userID int) (*params.AttachmentDownloadResponse, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	match, err := service.MatchRepository.FindMatchByID(ctx, tx, uint64(matchID))
+	if err != nil || !match.HasMember(uint64(userID)) {
+		return nil, response.NotFoundError("Match not found.")
+	}
+
+	attachment, err := service.AttachmentRepository.FindAttachmentByID(ctx, tx, uint64(attachmentID))
+	if err != nil || attachment.MatchID != match.Id {
+		return nil, response.NotFoundError("Attachment not found.")
+	}
+
+	content, err := service.Storage.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, response.NotFoundError("Attachment not found.")
+	}
+
+	return &params.AttachmentDownloadResponse{
+		Attachment: toAttachmentResponse(attachment),
+		Content:    content,
+	}, nil
+}
+
+func sniffContentType(head []byte) string {
+	contentType := http.DetectContentType(head)
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return contentType
+}
+
+func attachmentKind(contentType string) string {
+	for _, fileType := range attachmentTypes {
+		if fileType.ContentType == contentType {
+			return fileType.Kind
+		}
+	}
+	return ""
+}
+
+func toAttachmentResponse(attachment *models.Attachment) *params.AttachmentResponse {
+	return &params.AttachmentResponse{
+		Id:          attachment.Id,
+		MatchID:     attachment.MatchID,
+		Kind:        attachmentKind(attachment.ContentType),
+		ContentType: attachment.ContentType,
+		FileName:    attachment.FileName,
+		Size:        attachment.Size,
+		URL:         fmt.Sprintf("/api/messages/%d/attachments/%d", attachment.MatchID, attachment.Id),
+		CreatedAt:   attachment.CreatedAt,
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/middleware"
+	"sweatsparks/internal/models"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/repositories"
+	"sweatsparks/pkg/helpers"
+	"time"
+
+	"github.com/go-playground/validator"
+)
+
+type BlockService interface {
+	BlockUser(ctx context.Context, req *params.BlockRequest) (*params.BlockResponse, *response.CustomError)
+	UnblockUser(ctx context.Context, req *params.BlockRequest) *response.CustomError
+	GetBlockedUsers(ctx context.Context, userID int) ([]*params.BlockResponse, *response.CustomError)
+}
+
+type BlockServiceImpl struct {
+	MySqlDB         *sql.DB
+	BlockRepository repositories.BlockRepository
+	MatchRepository repositories.MatchRepository
+	Notifier        Notifier
+}
+
+func NewBlockService(db *sql.DB, blockRepository repositories.BlockRepository, matchRepository repositories.MatchRepository, notifier Notifier) BlockService {
+	return &BlockServiceImpl{
+		MySqlDB:         db,
+		BlockRepository: blockRepository,
+		MatchRepository: matchRepository,
+		Notifier:        notifier,
+	}
+}
+
+// BlockUser blocks a user and closes any match between the two. Closing the
+// match takes the conversation away from both sides, which is all the blocked
+// user gets to see of the block.
+func (service *BlockServiceImpl) BlockUser(ctx context.Context, req *params.BlockRequest) (*params.BlockResponse, *response.CustomError) {
+	if custErr := middleware.AuthorizeUser(ctx, req.BlockerID); custErr != nil {
+		return nil, custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
+	block, closed, custErr := service.createBlock(ctx, req)
+	if custErr != nil {
+		return nil, custErr
+	}
+
+	if closed != nil {
+		service.Notifier.Notify([]uint64{closed.UserOne, closed.UserTwo}, EventMatchClosed, closed.Id, &params.MatchClosedResponse{
+			MatchID: closed.Id,
+		})
+	}
+
+	return &params.BlockResponse{
+		UserID:    block.BlockedID,
+		BlockedAt: block.CreatedAt,
+	}, nil
+}
+
+// createBlock stores the block and returns the match it closed, if any.
+func (service *BlockServiceImpl) createBlock(ctx context.Context, req *params.BlockRequest) (*models.Block, *models.Match, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	var block = new(models.Block)
+	block.BlockerID = req.BlockerID
+	block.BlockedID = req.BlockedID
+	block.CreatedAt = time.Now()
+
+	err = service.BlockRepository.CreateBlock(ctx, tx, block)
+	if err != nil {
+		return nil, nil, response.GeneralError(err.Error())
+	}
+
+	match, err := service.MatchRepository.FindMatchByUserID(ctx, tx, req.BlockerID, req.BlockedID)
+	if err != nil {
+		return block, nil, nil
+	}
+	err = service.MatchRepository.CloseMatch(ctx, tx, match.Id, block.CreatedAt)
+	if err != nil {
+		return nil, nil, response.GeneralError(err.Error())
+	}
+	return block, match, nil
+}
+
+// UnblockUser lifts a block. A match closed by the block stays closed; the
+// two have to match again.
+func (service *BlockServiceImpl) UnblockUser(ctx context.Context, req *params.BlockRequest) *response.CustomError {
+	if custErr := middleware.AuthorizeUser(ctx, req.BlockerID); custErr != nil {
+		return custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return response.BadRequestError()
+	}
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	deleted, err := service.BlockRepository.DeleteBlock(ctx, tx, req.BlockerID, req.BlockedID)
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+	if deleted == 0 {
+		return response.NotFoundError("Block not found.")
+	}
+	return nil
+}
+
+func (service *BlockServiceImpl) GetBlockedUsers(ctx context.Context, userID int) ([]*params.BlockResponse, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	blocks, err := service.BlockRepository.FindBlocksByBlockerID(ctx, tx, uint64(userID))
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	result := []*params.BlockResponse{}
+	for _, block := range blocks {
+		result = append(result, &params.BlockResponse{
+			UserID:    block.BlockedID,
+			BlockedAt: block.CreatedAt,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/repositories"
+)
+
+// Features that can be held back until a user verifies their email, named
+// as they are listed in EMAIL_VERIFY_REQUIRED_FOR.
+const (
+	FeatureSwipe = "swipe"
+	FeatureChat  = "chat"
+)
+
+// EmailPolicy decides which features need a verified email.
+type EmailPolicy struct {
+	required map[string]bool
+}
+
+// NewEmailPolicy reads a comma separated feature list.
+func NewEmailPolicy(features string) *EmailPolicy {
+	policy := &EmailPolicy{required: make(map[string]bool)}
+	for _, feature := range strings.Split(features, ",") {
+		if feature = strings.TrimSpace(feature); feature != "" {
+			policy.required[feature] = true
+		}
+	}
+	return policy
+}
+
+// requireVerified returns the error for userID using feature without a
+// verified email, when the policy asks for one.
+func (policy *EmailPolicy) requireVerified(ctx context.Context, tx *sql.Tx, users repositories.UserRepository, userID uint64, feature string) *response.CustomError {
+	if !policy.required[feature] {
+		return nil
+	}
+
+	user, err := users.FindUserById(ctx, tx, int(userID))
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+	if !user.IsEmailVerified() {
+		return response.EmailNotVerifiedError("Verify your email to use " + feature + ".")
+	}
+	return nil
+}
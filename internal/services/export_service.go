@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/export"
+	"sweatsparks/internal/models"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/repositories"
+	"sweatsparks/pkg/helpers"
+	"time"
+
+	"github.com/go-playground/validator"
+)
+
+const exportPageSize = 500
+
+type ExportService interface {
+	ExportConversation(ctx context.Context, req *params.ConversationExportRequest, writer export.Writer) *response.CustomError
+}
+
+type ExportServiceImpl struct {
+	MySqlDB              *sql.DB
+	MessageRepository    repositories.MessageRepository
+	MatchRepository      repositories.MatchRepository
+	AttachmentRepository repositories.AttachmentRepository
+	ProfileRepository    repositories.ProfileRepository
+}
+
+func NewExportService(db *sql.DB, messageRepository repositories.MessageRepository, matchRepository repositories.MatchRepository, attachmentRepository repositories.AttachmentRepository, profileRepository repositories.ProfileRepository) ExportService {
+	return &ExportServiceImpl{
+		MySqlDB:              db,
+		MessageRepository:    messageRepository,
+		MatchRepository:      matchRepository,
+		AttachmentRepository: attachmentRepository,
+		ProfileRepository:    profileRepository,
+	}
+}
+
+// ExportConversation streams the whole conversation of a match, oldest message
+// first, as the caller sees it. Errors are only returned before anything has
+// been written; a failure halfway through is logged and cuts the export short.
+func (service *ExportServiceImpl) ExportConversation(ctx context.Context, req *params.ConversationExportRequest, writer export.Writer) *response.CustomError {
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return response.BadRequestError()
+	}
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	match, err := service.MatchRepository.FindMatchByID(ctx, tx, req.MatchID)
+	if err != nil || !match.HasMember(req.UserID) {
+		return response.NotFoundError("Match not found.")
+	}
+
+	names, err := service.ProfileRepository.FindFirstNamesByUserIDs(ctx, tx, []uint64{match.UserOne, match.UserTwo})
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+	for _, userID := range []uint64{match.UserOne, match.UserTwo} {
+		if names[userID] == "" {
+			names[userID] = fmt.Sprintf("User %d", userID)
+		}
+	}
+
+	err = writer.Begin(&export.Conversation{
+		MatchID:    match.Id,
+		ExportedAt: time.Now(),
+		Participants: []export.Participant{
+			{UserID: match.UserOne, Name: names[match.UserOne]},
+			{UserID: match.UserTwo, Name: names[match.UserTwo]},
+		},
+	})
+	if err != nil {
+		log.Printf("error exporting match %d: %v", match.Id, err)
+		return nil
+	}
+
+	if err := service.writeMessages(ctx, tx, match, req.UserID, names, writer); err != nil {
+		log.Printf("error exporting match %d: %v", match.Id, err)
+		return nil
+	}
+
+	if err := writer.End(); err != nil {
+		log.Printf("error exporting match %d: %v", match.Id, err)
+	}
+	return nil
+}
+
+// writeMessages pages forward from the first message of the conversation so
+// only one page is held in memory at a time.
+func (service *ExportServiceImpl) writeMessages(ctx context.Context, tx *sql.Tx, match *models.Match, viewerID uint64, names map[uint64]string, writer export.Writer) error {
+	var cursor uint64
+	for {
+		// Paging forward returns the page newest first.
+		messages, err := service.MessageRepository.FindMessagesByMatchID(ctx, tx, match.Id, viewerID, 0, cursor, true, exportPageSize)
+		if err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		var attachmentIDs []uint64
+		for _, message := range messages {
+			if message.AttachmentID.Valid {
+				attachmentIDs = append(attachmentIDs, uint64(message.AttachmentID.Int64))
+			}
+		}
+		attachments, err := service.AttachmentRepository.FindAttachmentsByIDs(ctx, tx, attachmentIDs)
+		if err != nil {
+			return err
+		}
+		byID := make(map[uint64]*models.Attachment)
+		for _, attachment := range attachments {
+			byID[attachment.Id] = attachment
+		}
+
+		for i := len(messages) - 1; i >= 0; i-- {
+			message := messages[i]
+			item := &export.Message{
+				ID:         message.Id,
+				SenderID:   message.SenderID,
+				SenderName: names[message.SenderID],
+				Type:       message.Type,
+				Content:    message.Content,
+				SentAt:     message.SendAt,
+				EditedAt:   nullTimePtr(message.EditedAt),
+				Unsent:     message.DeletedAt.Valid,
+			}
+			if attachment, ok := byID[uint64(message.AttachmentID.Int64)]; ok && message.AttachmentID.Valid {
+				file := toAttachmentResponse(attachment)
+				item.Attachment = &export.Attachment{
+					FileName:    file.FileName,
+					ContentType: file.ContentType,
+					URL:         file.URL,
+				}
+			}
+			if err := writer.WriteMessage(item); err != nil {
+				return err
+			}
+		}
+
+		if len(messages) < exportPageSize {
+			return nil
+		}
+		cursor = messages[0].Id
+	}
+}
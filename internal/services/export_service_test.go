@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sort"
+	"sweatsparks/internal/export"
+	"sweatsparks/internal/models"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/repositories"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// txDriver hands out connections whose transactions do nothing, so services
+// can begin and commit transactions against fake repositories.
+type txDriver struct{}
+
+func (txDriver) Open(name string) (driver.Conn, error) { return txConn{}, nil }
+
+type txConn struct{}
+
+func (txConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("txConn does not run queries")
+}
+func (txConn) Close() error              { return nil }
+func (txConn) Begin() (driver.Tx, error) { return txConn{}, nil }
+func (txConn) Commit() error             { return nil }
+func (txConn) Rollback() error           { return nil }
+
+func init() {
+	sql.Register("services_test", txDriver{})
+}
+
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("services_test", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// fakeMessageRepository pages through messages, kept in id order, the way
+// MessageRepositoryImpl.FindMessagesByMatchID does.
+type fakeMessageRepository struct {
+	repositories.MessageRepository
+	messages []*models.Message
+}
+
+func (repository *fakeMessageRepository) FindMessagesByMatchID(ctx context.Context, tx *sql.Tx, matchID, viewerID, before, after uint64, forward bool, limit int) ([]*models.Message, error) {
+	var page []*models.Message
+	if forward {
+		for _, message := range repository.messages {
+			if message.MatchID == matchID && message.Id > after && len(page) < limit {
+				page = append(page, message)
+			}
+		}
+		sort.Slice(page, func(i, j int) bool { return page[i].Id > page[j].Id })
+	} else {
+		for i := len(repository.messages) - 1; i >= 0; i-- {
+			message := repository.messages[i]
+			if message.MatchID == matchID && (before == 0 || message.Id < before) && len(page) < limit {
+				page = append(page, message)
+			}
+		}
+	}
+	return page, nil
+}
+
+type fakeMatchRepository struct {
+	repositories.MatchRepository
+	match *models.Match
+}
+
+func (repository *fakeMatchRepository) FindMatchByID(ctx context.Context, tx *sql.Tx, matchID uint64) (*models.Match, error) {
+	if repository.match == nil || repository.match.Id != matchID {
+		return nil, errors.New("match is not found")
+	}
+	return repository.match, nil
+}
+
+type fakeAttachmentRepository struct {
+	repositories.AttachmentRepository
+}
+
+func (repository *fakeAttachmentRepository) FindAttachmentsByIDs(ctx context.Context, tx *sql.Tx, ids []uint64) ([]*models.Attachment, error) {
+	return nil, nil
+}
+
+type fakeProfileRepository struct {
+	repositories.ProfileRepository
+}
+
+func (repository *fakeProfileRepository) FindFirstNamesByUserIDs(ctx context.Context, tx *sql.Tx, userIDs []uint64) (map[uint64]string, error) {
+	return map[uint64]string{}, nil
+}
+
+type recordingWriter struct {
+	messages []*export.Message
+}
+
+func (writer *recordingWriter) ContentType() string { return "text/plain" }
+func (writer *recordingWriter) Begin(conversation *export.Conversation) error {
+	return nil
+}
+func (writer *recordingWriter) WriteMessage(message *export.Message) error {
+	writer.messages = append(writer.messages, message)
+	return nil
+}
+func (writer *recordingWriter) End() error { return nil }
+
+func TestExportConversationWritesEveryPage(t *testing.T) {
+	match := &models.Match{Id: 1, UserOne: 10, UserTwo: 20}
+	messages := &fakeMessageRepository{}
+	total := 2*exportPageSize + 7
+	for id := 1; id <= total; id++ {
+		messages.messages = append(messages.messages, &models.Message{Id: uint64(id), MatchID: match.Id, SenderID: match.UserOne})
+	}
+
+	service := NewExportService(openTestDB(t), messages, &fakeMatchRepository{match: match}, &fakeAttachmentRepository{}, &fakeProfileRepository{})
+	writer := &recordingWriter{}
+	err := service.ExportConversation(context.Background(), &params.ConversationExportRequest{MatchID: match.Id, UserID: match.UserTwo, Format: "txt"}, writer)
+	require.Nil(t, err)
+
+	require.Len(t, writer.messages, total)
+	for i, message := range writer.messages {
+		require.Equal(t, uint64(i+1), message.ID)
+	}
+}
@@ -8,13 +8,10 @@ import (
 	"sweatsparks/internal/params"
 	"sweatsparks/internal/repositories"
 	"sweatsparks/pkg/helpers"
-	"time"
-
-	"github.com/go-playground/validator"
 )
 
 type MatchService interface {
-	CreateMatchUser(ctx context.Context, req *params.MatchRequest) (*params.MatchDetailResponse, *response.CustomError)
+	FindMatchDetailByID(ctx context.Context, matchID, userID int) (*params.MatchDetailResponse, *response.CustomError)
 	FindMatchDetailByUserID(ctx context.Context, userID1, UserID2 int) (*params.MatchDetailResponse, *response.CustomError)
 	FindMatchAllByUserID(ctx context.Context, userID int) ([]*params.MatchDetailResponse, *response.CustomError)
 }
@@ -31,41 +28,19 @@ func NewMatchService(db *sql.DB, matchRepository repositories.MatchRepository) M
 	}
 }
 
-func (service *MatchServiceImpl) CreateMatchUser(ctx context.Context, req *params.MatchRequest) (*params.MatchDetailResponse, *response.CustomError) {
-	val := validator.New()
-	err := val.Struct(req)
-	if err != nil {
-		return nil, response.BadRequestError()
-	}
-
+func (service *MatchServiceImpl) FindMatchDetailByID(ctx context.Context, matchID, userID int) (*params.MatchDetailResponse, *response.CustomError) {
 	tx, err := service.MySqlDB.Begin()
 	if err != nil {
 		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
 	}
 	defer helpers.CommitOrRollback(tx)
 
-	_, err = service.MatchRepository.FindMatchByUserID(ctx, tx, uint64(req.UserOne), uint64(req.UserTwo))
-	if err == nil {
-		return nil, response.BadRequestErrorWithAdditionalInfo("User has been in match.")
-	}
-
-	var match = new(models.Match)
-
-	match.UserOne = uint64(req.UserOne)
-	match.UserTwo = uint64(req.UserTwo)
-	match.MatchedTime = time.Now()
-
-	err = service.MatchRepository.CreateMatch(ctx, tx, match)
-	if err != nil {
-		return nil, response.GeneralError(err.Error())
+	result, err := service.MatchRepository.FindMatchByID(ctx, tx, uint64(matchID))
+	if err != nil || !result.HasMember(uint64(userID)) {
+		return nil, response.NotFoundError("Match not found.")
 	}
 
-	return &params.MatchDetailResponse{
-		Id:          match.Id,
-		UserOne:     match.UserOne,
-		UserTwo:     match.UserTwo,
-		MatchedTime: match.MatchedTime,
-	}, nil
+	return toMatchDetailResponse(result), nil
 }
 
 func (service *MatchServiceImpl) FindMatchDetailByUserID(ctx context.Context, userID1, UserID2 int) (*params.MatchDetailResponse, *response.CustomError) {
@@ -80,12 +55,7 @@ func (service *MatchServiceImpl) FindMatchDetailByUserID(ctx context.Context, us
 		return nil, response.BadRequestErrorWithAdditionalInfo("Email has been registered.")
 	}
 
-	return &params.MatchDetailResponse{
-		Id:          result.Id,
-		UserOne:     result.UserOne,
-		UserTwo:     result.UserTwo,
-		MatchedTime: result.MatchedTime,
-	}, nil
+	return toMatchDetailResponse(result), nil
 }
 
 func (service *MatchServiceImpl) FindMatchAllByUserID(ctx context.Context, userID int) ([]*params.MatchDetailResponse, *response.CustomError) {
@@ -103,13 +73,37 @@ func (service *MatchServiceImpl) FindMatchAllByUserID(ctx context.Context, userI
 	var result []*params.MatchDetailResponse
 
 	for _, match := range matches {
-		result = append(result, &params.MatchDetailResponse{
-			Id:          match.Id,
-			UserOne:     match.UserOne,
-			UserTwo:     match.UserTwo,
-			MatchedTime: match.MatchedTime,
-		})
+		result = append(result, toMatchDetailResponse(match))
 	}
 
 	return result, nil
 }
+
+// disappearDurations are the lifetimes a match can give its messages, in
+// seconds, keyed by the label clients send and receive.
+var disappearDurations = map[string]int64{
+	"off": 0,
+	"1h":  60 * 60,
+	"24h": 24 * 60 * 60,
+	"7d":  7 * 24 * 60 * 60,
+}
+
+func disappearLabel(seconds int64) string {
+	for label, duration := range disappearDurations {
+		if duration == seconds {
+			return label
+		}
+	}
+	return "off"
+}
+
+func toMatchDetailResponse(match *models.Match) *params.MatchDetailResponse {
+	return &params.MatchDetailResponse{
+		Id:               match.Id,
+		UserOne:          match.UserOne,
+		UserTwo:          match.UserTwo,
+		MatchedTime:      match.MatchedTime,
+		DisappearAfter:   disappearLabel(match.DisappearAfter),
+		DisappearTrigger: match.DisappearTrigger,
+	}
+}
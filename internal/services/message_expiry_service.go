@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/models"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/repositories"
+	"sweatsparks/internal/search"
+	"sweatsparks/pkg/helpers"
+	"sweatsparks/pkg/storage"
+	"time"
+)
+
+const expiredMessageBatchSize = 500
+
+type MessageExpiryService interface {
+	SweepExpiredMessages(ctx context.Context) (int, *response.CustomError)
+}
+
+type MessageExpiryServiceImpl struct {
+	MySqlDB              *sql.DB
+	MessageRepository    repositories.MessageRepository
+	MatchRepository      repositories.MatchRepository
+	AttachmentRepository repositories.AttachmentRepository
+	Storage              storage.Storage
+	SearchIndex          search.Index
+	Notifier             Notifier
+}
+
+func NewMessageExpiryService(db *sql.DB, messageRepository repositories.MessageRepository, matchRepository repositories.MatchRepository, attachmentRepository repositories.AttachmentRepository, store storage.Storage, searchIndex search.Index, notifier Notifier) MessageExpiryService {
+	return &MessageExpiryServiceImpl{
+		MySqlDB:              db,
+		MessageRepository:    messageRepository,
+		MatchRepository:      matchRepository,
+		AttachmentRepository: attachmentRepository,
+		Storage:              store,
+		SearchIndex:          searchIndex,
+		Notifier:             notifier,
+	}
+}
+
+// SweepExpiredMessages hard deletes every expired message and its attachment
+// and tells both participants of each match which messages are gone. It
+// returns how many messages were deleted.
+func (service *MessageExpiryServiceImpl) SweepExpiredMessages(ctx context.Context) (int, *response.CustomError) {
+	total := 0
+	for {
+		deleted, custErr := service.sweepBatch(ctx)
+		if custErr != nil {
+			return total, custErr
+		}
+		total += deleted
+		if deleted < expiredMessageBatchSize {
+			return total, nil
+		}
+	}
+}
+
+func (service *MessageExpiryServiceImpl) sweepBatch(ctx context.Context) (int, *response.CustomError) {
+	messages, attachments, matches, custErr := service.deleteExpired(ctx)
+	if custErr != nil {
+		return 0, custErr
+	}
+
+	// Files go only once the rows are committed, so a failed sweep never
+	// leaves a message pointing at a missing file.
+	for _, attachment := range attachments {
+		service.Storage.Delete(ctx, attachment.StorageKey)
+	}
+
+	expired := make(map[uint64][]uint64)
+	var messageIDs []uint64
+	for _, message := range messages {
+		expired[message.MatchID] = append(expired[message.MatchID], message.Id)
+		messageIDs = append(messageIDs, message.Id)
+	}
+	if err := service.SearchIndex.Remove(ctx, messageIDs...); err != nil {
+		log.Printf("error removing expired messages from search index: %v", err)
+	}
+	for matchID, messageIDs := range expired {
+		match, ok := matches[matchID]
+		if !ok {
+			continue
+		}
+		service.Notifier.Notify([]uint64{match.UserOne, match.UserTwo}, EventMessageExpired, match.Id, &params.MessageExpiredResponse{
+			MatchID:    match.Id,
+			MessageIDs: messageIDs,
+		})
+	}
+
+	return len(messages), nil
+}
+
+func (service *MessageExpiryServiceImpl) deleteExpired(ctx context.Context) ([]*models.Message, []*models.Attachment, map[uint64]*models.Match, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, nil, nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	messages, err := service.MessageRepository.FindExpiredMessages(ctx, tx, time.Now(), expiredMessageBatchSize)
+	if err != nil {
+		return nil, nil, nil, response.GeneralError(err.Error())
+	}
+	if len(messages) == 0 {
+		return nil, nil, nil, nil
+	}
+
+	var messageIDs, attachmentIDs []uint64
+	matches := make(map[uint64]*models.Match)
+	for _, message := range messages {
+		messageIDs = append(messageIDs, message.Id)
+		if message.AttachmentID.Valid {
+			attachmentIDs = append(attachmentIDs, uint64(message.AttachmentID.Int64))
+		}
+		if _, ok := matches[message.MatchID]; !ok {
+			match, err := service.MatchRepository.FindMatchByID(ctx, tx, message.MatchID)
+			if err == nil {
+				matches[match.Id] = match
+			}
+		}
+	}
+
+	attachments, err := service.AttachmentRepository.FindAttachmentsByIDs(ctx, tx, attachmentIDs)
+	if err != nil {
+		return nil, nil, nil, response.GeneralError(err.Error())
+	}
+
+	err = service.MessageRepository.DeleteMessages(ctx, tx, messageIDs)
+	if err != nil {
+		return nil, nil, nil, response.GeneralError(err.Error())
+	}
+
+	for _, attachment := range attachments {
+		err = service.AttachmentRepository.DeleteAttachment(ctx, tx, attachment.Id)
+		if err != nil {
+			return nil, nil, nil, response.GeneralError(err.Error())
+		}
+	}
+
+	return messages, attachments, matches, nil
+}
@@ -3,50 +3,811 @@ package services
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"log"
 	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/middleware"
+	"sweatsparks/internal/models"
 	"sweatsparks/internal/params"
 	"sweatsparks/internal/repositories"
+	"sweatsparks/internal/search"
 	"sweatsparks/pkg/helpers"
+	"sweatsparks/pkg/storage"
+	"time"
+
+	"github.com/go-playground/validator"
 )
 
 type MessageService interface {
-	GetMessageByMatchId(ctx context.Context, id int) ([]*params.MessageResponse, *response.CustomError)
+	SendMessage(ctx context.Context, req *params.MessageRequest) (*params.MessageSendResponse, *response.CustomError)
+	MarkMessagesDelivered(ctx context.Context, req *params.MessageReceiptRequest) (*params.MessageReceiptResponse, *response.CustomError)
+	MarkMessagesRead(ctx context.Context, req *params.MessageReceiptRequest) (*params.MessageReceiptResponse, *response.CustomError)
+	GetMessageByMatchId(ctx context.Context, req *params.MessageHistoryRequest) (*params.ConversationResponse, *response.CustomError)
+	EditMessage(ctx context.Context, req *params.MessageEditRequest) (*params.MessageResponse, *response.CustomError)
+	GetMessageEdits(ctx context.Context, req *params.MessageDeleteRequest) ([]*params.MessageEditResponse, *response.CustomError)
+	UnsendMessage(ctx context.Context, req *params.MessageDeleteRequest) (*params.MessageResponse, *response.CustomError)
+	DeleteMessageForMe(ctx context.Context, req *params.MessageDeleteRequest) *response.CustomError
+	UpdateDisappearing(ctx context.Context, req *params.DisappearingRequest) (*params.DisappearingResponse, *response.CustomError)
+	SearchMessages(ctx context.Context, req *params.MessageSearchRequest) (*params.MessageSearchResponse, *response.CustomError)
 }
 
 type MessageServiceImpl struct {
-	MySqlDB           *sql.DB
-	MessageRepository repositories.MessageRepository
+	MySqlDB              *sql.DB
+	MessageRepository    repositories.MessageRepository
+	MatchRepository      repositories.MatchRepository
+	AttachmentRepository repositories.AttachmentRepository
+	ReactionRepository   repositories.ReactionRepository
+	UserRepository       repositories.UserRepository
+	Storage              storage.Storage
+	SearchIndex          search.Index
+	Notifier             Notifier
+	EmailPolicy          *EmailPolicy
+	UnsendWindow         time.Duration
 }
 
-func NewMessageService(db *sql.DB, messageRepository repositories.MessageRepository) MessageService {
+func NewMessageService(db *sql.DB, messageRepository repositories.MessageRepository, matchRepository repositories.MatchRepository, attachmentRepository repositories.AttachmentRepository, reactionRepository repositories.ReactionRepository, userRepository repositories.UserRepository, store storage.Storage, searchIndex search.Index, notifier Notifier, emailPolicy *EmailPolicy, unsendWindow time.Duration) MessageService {
 	return &MessageServiceImpl{
-		MySqlDB:           db,
-		MessageRepository: messageRepository,
+		MySqlDB:              db,
+		MessageRepository:    messageRepository,
+		MatchRepository:      matchRepository,
+		AttachmentRepository: attachmentRepository,
+		ReactionRepository:   reactionRepository,
+		UserRepository:       userRepository,
+		Storage:              store,
+		SearchIndex:          searchIndex,
+		Notifier:             notifier,
+		EmailPolicy:          emailPolicy,
+		UnsendWindow:         unsendWindow,
 	}
 }
 
-func (service *MessageServiceImpl) GetMessageByMatchId(ctx context.Context, id int) ([]*params.MessageResponse, *response.CustomError) {
+func (service *MessageServiceImpl) SendMessage(ctx context.Context, req *params.MessageRequest) (*params.MessageSendResponse, *response.CustomError) {
+	if custErr := middleware.AuthorizeUser(ctx, req.SenderID); custErr != nil {
+		return nil, custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
 	tx, err := service.MySqlDB.Begin()
 	if err != nil {
 		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
 	}
 	defer helpers.CommitOrRollback(tx)
 
-	messages, err := service.MessageRepository.GetMessageByMatchID(ctx, tx, id)
+	if custErr := service.EmailPolicy.requireVerified(ctx, tx, service.UserRepository, req.SenderID, FeatureChat); custErr != nil {
+		return nil, custErr
+	}
+
+	match, err := service.MatchRepository.FindMatchByID(ctx, tx, req.MatchID)
+	if err != nil || !match.HasMember(req.SenderID) {
+		return nil, response.NotFoundError("Match not found.")
+	}
+	recipients, err := matchRecipients(ctx, tx, service.UserRepository, match, req.SenderID)
 	if err != nil {
-		return nil, response.BadRequestErrorWithAdditionalInfo("messages not found.")
+		return nil, response.GeneralError(err.Error())
 	}
 
-	var result []*params.MessageResponse
-	for _, msg := range messages {
-		result = append(result, &params.MessageResponse{
-			Id:       msg.Id,
-			MatchID:  msg.MatchID,
-			SenderID: msg.SenderID,
-			Content:  msg.Content,
-			SendAt:   msg.SendAt,
+	existing, err := service.MessageRepository.FindMessageByClientID(ctx, tx, req.SenderID, req.ClientMessageID)
+	if err == nil {
+		return service.duplicateMessage(ctx, tx, match.Id, existing, recipients)
+	}
+
+	var message = new(models.Message)
+	message.MatchID = match.Id
+	message.SenderID = req.SenderID
+	message.ClientMessageID = sql.NullString{String: req.ClientMessageID, Valid: true}
+	message.Type = models.MessageTypeText
+	message.Content = req.Content
+	message.SendAt = time.Now()
+	applyDisappearing(message, match)
+
+	var attachment *models.Attachment
+	if req.AttachmentID > 0 {
+		attachment, err = service.AttachmentRepository.FindAttachmentByID(ctx, tx, req.AttachmentID)
+		if err != nil || attachment.MatchID != match.Id || attachment.UploaderID != req.SenderID {
+			return nil, response.BadRequestErrorWithAdditionalInfo("Attachment not found.")
+		}
+
+		linked, err := service.AttachmentRepository.IsAttachmentLinked(ctx, tx, attachment.Id)
+		if err != nil {
+			return nil, response.GeneralError(err.Error())
+		}
+		if linked {
+			return nil, response.BadRequestErrorWithAdditionalInfo("Attachment has already been sent.")
+		}
+
+		message.AttachmentID = sql.NullInt64{Int64: int64(attachment.Id), Valid: true}
+	}
+
+	err = service.MessageRepository.CreateMessage(ctx, tx, message)
+	if err != nil {
+		// A concurrent retry may have won the race on the unique key. Its row
+		// is newer than our snapshot, so only a locking read can see it.
+		existing, findErr := service.MessageRepository.LockMessageByClientID(ctx, tx, req.SenderID, req.ClientMessageID)
+		if findErr == nil {
+			return service.duplicateMessage(ctx, tx, match.Id, existing, recipients)
+		}
+		return nil, response.GeneralError(err.Error())
+	}
+	service.indexMessage(ctx, message)
+
+	result := toMessageResponse(message)
+	if attachment != nil {
+		result.Attachment = toAttachmentResponse(attachment)
+	}
+
+	return &params.MessageSendResponse{
+		Message:    result,
+		Recipients: recipients,
+	}, nil
+}
+
+// duplicateMessage acknowledges a retried send with the message stored the
+// first time. A client message ID reused in another match is rejected rather
+// than acknowledged with a message from the wrong conversation.
+func (service *MessageServiceImpl) duplicateMessage(ctx context.Context, tx *sql.Tx, matchID uint64, message *models.Message, recipients []uint64) (*params.MessageSendResponse, *response.CustomError) {
+	if message.MatchID != matchID {
+		return nil, response.BadRequestErrorWithAdditionalInfo("Client message ID has already been used.")
+	}
+
+	result, err := service.toMessageResponses(ctx, tx, []*models.Message{message})
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	return &params.MessageSendResponse{
+		Message:    result[0],
+		Recipients: recipients,
+		Duplicate:  true,
+	}, nil
+}
+
+func (service *MessageServiceImpl) MarkMessagesDelivered(ctx context.Context, req *params.MessageReceiptRequest) (*params.MessageReceiptResponse, *response.CustomError) {
+	return service.markMessages(ctx, req, service.MessageRepository.MarkMessagesDelivered)
+}
+
+func (service *MessageServiceImpl) MarkMessagesRead(ctx context.Context, req *params.MessageReceiptRequest) (*params.MessageReceiptResponse, *response.CustomError) {
+	result, custErr := service.markMessages(ctx, req, service.MessageRepository.MarkMessagesRead)
+	if custErr != nil {
+		return nil, custErr
+	}
+
+	if result.Updated > 0 {
+		if err := service.SearchIndex.MarkRead(ctx, result.MatchID, result.UserID, result.MessageID, result.At); err != nil {
+			log.Printf("error marking messages read in search index for match %d: %v", result.MatchID, err)
+		}
+	}
+	return result, nil
+}
+
+func (service *MessageServiceImpl) markMessages(ctx context.Context, req *params.MessageReceiptRequest, mark func(context.Context, *sql.Tx, uint64, uint64, uint64, time.Time) (int64, error)) (*params.MessageReceiptResponse, *response.CustomError) {
+	if custErr := middleware.AuthorizeUser(ctx, req.UserID); custErr != nil {
+		return nil, custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	match, err := service.MatchRepository.FindMatchByID(ctx, tx, req.MatchID)
+	if err != nil || !match.HasMember(req.UserID) {
+		return nil, response.NotFoundError("Match not found.")
+	}
+
+	now := time.Now()
+	updated, err := mark(ctx, tx, match.Id, req.UserID, req.MessageID, now)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	return &params.MessageReceiptResponse{
+		MatchID:    match.Id,
+		UserID:     req.UserID,
+		MessageID:  req.MessageID,
+		At:         now,
+		Updated:    updated,
+		Recipients: []uint64{match.UserOne, match.UserTwo},
+	}, nil
+}
+
+const defaultMessagePageSize = 50
+
+func (service *MessageServiceImpl) GetMessageByMatchId(ctx context.Context, req *params.MessageHistoryRequest) (*params.ConversationResponse, *response.CustomError) {
+	val := validator.New()
+	err := val.Struct(req)
+	forward := req.After > 0 || req.Forward
+	if err != nil || (req.Before > 0 && forward) {
+		return nil, response.BadRequestError()
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultMessagePageSize
+	}
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	match, err := service.MatchRepository.FindMatchByID(ctx, tx, req.MatchID)
+	if err != nil || !match.HasMember(req.UserID) {
+		return nil, response.NotFoundError("Match not found.")
+	}
+
+	// One extra row tells us whether another page exists.
+	messages, err := service.MessageRepository.FindMessagesByMatchID(ctx, tx, match.Id, req.UserID, req.Before, req.After, forward, limit+1)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	unread, err := service.MessageRepository.CountUnreadMessages(ctx, tx, match.Id, req.UserID)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	var result = &params.ConversationResponse{
+		MatchID:     match.Id,
+		UnreadCount: unread,
+	}
+
+	if len(messages) > limit {
+		result.HasMore = true
+		if forward {
+			messages = messages[1:]
+		} else {
+			messages = messages[:limit]
+		}
+	}
+
+	result.Messages, err = service.toMessageResponses(ctx, tx, messages)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	if result.HasMore {
+		// Paging forward continues from the newest message, paging back
+		// from the oldest one.
+		cursor := messages[len(messages)-1].Id
+		if forward {
+			cursor = messages[0].Id
+		}
+		result.NextCursor = &cursor
+	}
+
+	return result, nil
+}
+
+// UpdateDisappearing changes the lifetime of messages sent in a match from now
+// on and posts a system message about it to both participants.
+func (service *MessageServiceImpl) UpdateDisappearing(ctx context.Context, req *params.DisappearingRequest) (*params.DisappearingResponse, *response.CustomError) {
+	if custErr := middleware.AuthorizeUser(ctx, req.UserID); custErr != nil {
+		return nil, custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
+	trigger := req.Trigger
+	if trigger == "" {
+		trigger = models.DisappearOnRead
+	}
+	after := disappearDurations[req.Duration]
+
+	result, recipients, custErr := service.updateDisappearing(ctx, req, after, trigger)
+	if custErr != nil {
+		return nil, custErr
+	}
+
+	if result.Message != nil {
+		service.Notifier.Notify(recipients, EventMessageNew, result.MatchID, result.Message)
+	}
+	return result, nil
+}
+
+// updateDisappearing stores the new setting and its system message. The
+// message is nil when the setting did not change.
+func (service *MessageServiceImpl) updateDisappearing(ctx context.Context, req *params.DisappearingRequest, after int64, trigger string) (*params.DisappearingResponse, []uint64, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	match, err := service.MatchRepository.FindMatchByID(ctx, tx, req.MatchID)
+	if err != nil || !match.HasMember(req.UserID) {
+		return nil, nil, response.NotFoundError("Match not found.")
+	}
+
+	result := &params.DisappearingResponse{
+		MatchID:  match.Id,
+		Duration: req.Duration,
+		Trigger:  trigger,
+	}
+	if match.DisappearAfter == after && (after == 0 || match.DisappearTrigger == trigger) {
+		return result, nil, nil
+	}
+
+	err = service.MatchRepository.UpdateDisappearing(ctx, tx, match.Id, after, trigger)
+	if err != nil {
+		return nil, nil, response.GeneralError(err.Error())
+	}
+
+	content := "Disappearing messages turned off."
+	if after > 0 {
+		content = fmt.Sprintf("Disappearing messages set to %s after they are %s.", req.Duration, trigger)
+	}
+
+	var message = new(models.Message)
+	message.MatchID = match.Id
+	message.SenderID = req.UserID
+	message.Type = models.MessageTypeSystem
+	message.Content = content
+	message.SendAt = time.Now()
+
+	err = service.MessageRepository.CreateMessage(ctx, tx, message)
+	if err != nil {
+		return nil, nil, response.GeneralError(err.Error())
+	}
+	result.Message = toMessageResponse(message)
+
+	recipients, err := matchRecipients(ctx, tx, service.UserRepository, match, req.UserID)
+	if err != nil {
+		return nil, nil, response.GeneralError(err.Error())
+	}
+	return result, recipients, nil
+}
+
+// applyDisappearing stamps a new message with the match's expiry. Messages
+// expiring after being read only get their deadline once the reader sees them.
+func applyDisappearing(message *models.Message, match *models.Match) {
+	if match.DisappearAfter == 0 {
+		return
+	}
+
+	if match.DisappearTrigger == models.DisappearOnSent {
+		expiresAt := message.SendAt.Add(time.Duration(match.DisappearAfter) * time.Second)
+		message.ExpiresAt = sql.NullTime{Time: expiresAt, Valid: true}
+		return
+	}
+	message.ExpireAfter = sql.NullInt64{Int64: match.DisappearAfter, Valid: true}
+}
+
+// EditMessage replaces the content of one of the caller's messages, keeping
+// the previous content in the edit history, and pushes the new version to both
+// participants.
+func (service *MessageServiceImpl) EditMessage(ctx context.Context, req *params.MessageEditRequest) (*params.MessageResponse, *response.CustomError) {
+	if custErr := middleware.AuthorizeUser(ctx, req.UserID); custErr != nil {
+		return nil, custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
+	result, recipients, custErr := service.editMessage(ctx, req)
+	if custErr != nil {
+		return nil, custErr
+	}
+
+	service.Notifier.Notify(recipients, EventMessageEdited, result.MatchID, result)
+	return result, nil
+}
+
+func (service *MessageServiceImpl) editMessage(ctx context.Context, req *params.MessageEditRequest) (*params.MessageResponse, []uint64, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	match, message, custErr := service.findOwnMessage(ctx, tx, req.MatchID, req.MessageID, req.UserID)
+	if custErr != nil {
+		return nil, nil, custErr
+	}
+	if message.Content == req.Content {
+		return nil, nil, response.BadRequestErrorWithAdditionalInfo("Message content is unchanged.")
+	}
+
+	now := time.Now()
+	err = service.MessageRepository.CreateMessageEdit(ctx, tx, &models.MessageEdit{
+		MessageID: message.Id,
+		Content:   message.Content,
+		EditedAt:  now,
+	})
+	if err != nil {
+		return nil, nil, response.GeneralError(err.Error())
+	}
+
+	err = service.MessageRepository.UpdateMessageContent(ctx, tx, message.Id, req.Content, now)
+	if err != nil {
+		return nil, nil, response.GeneralError(err.Error())
+	}
+	message.Content = req.Content
+	message.EditedAt = sql.NullTime{Time: now, Valid: true}
+	service.indexMessage(ctx, message)
+
+	result, err := service.toMessageResponses(ctx, tx, []*models.Message{message})
+	if err != nil {
+		return nil, nil, response.GeneralError(err.Error())
+	}
+
+	recipients, err := matchRecipients(ctx, tx, service.UserRepository, match, req.UserID)
+	if err != nil {
+		return nil, nil, response.GeneralError(err.Error())
+	}
+	return result[0], recipients, nil
+}
+
+// GetMessageEdits returns the earlier versions of a message, oldest first.
+func (service *MessageServiceImpl) GetMessageEdits(ctx context.Context, req *params.MessageDeleteRequest) ([]*params.MessageEditResponse, *response.CustomError) {
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	_, message, custErr := service.findMessage(ctx, tx, req.MatchID, req.MessageID, req.UserID)
+	if custErr != nil {
+		return nil, custErr
+	}
+
+	edits, err := service.MessageRepository.FindMessageEdits(ctx, tx, message.Id)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	result := []*params.MessageEditResponse{}
+	for _, edit := range edits {
+		result = append(result, &params.MessageEditResponse{
+			Id:       edit.Id,
+			Content:  edit.Content,
+			EditedAt: edit.EditedAt,
+		})
+	}
+	return result, nil
+}
+
+// UnsendMessage removes one of the caller's messages for both participants,
+// as long as it was sent within the unsend window. The message stays in the
+// history as an empty tombstone so clients can show that it was unsent.
+func (service *MessageServiceImpl) UnsendMessage(ctx context.Context, req *params.MessageDeleteRequest) (*params.MessageResponse, *response.CustomError) {
+	if custErr := middleware.AuthorizeUser(ctx, req.UserID); custErr != nil {
+		return nil, custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
+	result, attachment, recipients, custErr := service.unsendMessage(ctx, req)
+	if custErr != nil {
+		return nil, custErr
+	}
+
+	// The file goes only once the rows are committed, so a failed unsend
+	// never leaves a message pointing at a missing file.
+	if attachment != nil {
+		service.Storage.Delete(ctx, attachment.StorageKey)
+	}
+	if err := service.SearchIndex.Remove(ctx, result.Id); err != nil {
+		log.Printf("error removing message %d from search index: %v", result.Id, err)
+	}
+
+	service.Notifier.Notify(recipients, EventMessageUnsent, result.MatchID, result)
+	return result, nil
+}
+
+func (service *MessageServiceImpl) unsendMessage(ctx context.Context, req *params.MessageDeleteRequest) (*params.MessageResponse, *models.Attachment, []uint64, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, nil, nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	match, message, custErr := service.findOwnMessage(ctx, tx, req.MatchID, req.MessageID, req.UserID)
+	if custErr != nil {
+		return nil, nil, nil, custErr
+	}
+	if time.Since(message.SendAt) > service.UnsendWindow {
+		return nil, nil, nil, response.BadRequestErrorWithAdditionalInfo("Message can no longer be unsent.")
+	}
+
+	var attachment *models.Attachment
+	if message.AttachmentID.Valid {
+		attachment, err = service.AttachmentRepository.FindAttachmentByID(ctx, tx, uint64(message.AttachmentID.Int64))
+		if err != nil {
+			return nil, nil, nil, response.GeneralError(err.Error())
+		}
+	}
+
+	now := time.Now()
+	err = service.MessageRepository.UnsendMessage(ctx, tx, message.Id, now)
+	if err != nil {
+		return nil, nil, nil, response.GeneralError(err.Error())
+	}
+
+	// From here on a failure rolls the unsend back, so the message is never
+	// left unsent while its attachment row and file are kept.
+	if attachment != nil {
+		err = service.AttachmentRepository.DeleteAttachment(ctx, tx, attachment.Id)
+		if err != nil {
+			tx.Rollback()
+			return nil, nil, nil, response.GeneralError(err.Error())
+		}
+	}
+
+	message.Content = ""
+	message.AttachmentID = sql.NullInt64{}
+	message.DeletedAt = sql.NullTime{Time: now, Valid: true}
+
+	recipients, err := matchRecipients(ctx, tx, service.UserRepository, match, req.UserID)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, nil, response.GeneralError(err.Error())
+	}
+	return toMessageResponse(message), attachment, recipients, nil
+}
+
+// DeleteMessageForMe hides a message from the caller's history only. The
+// caller's other devices are told to drop it too.
+func (service *MessageServiceImpl) DeleteMessageForMe(ctx context.Context, req *params.MessageDeleteRequest) *response.CustomError {
+	if custErr := middleware.AuthorizeUser(ctx, req.UserID); custErr != nil {
+		return custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return response.BadRequestError()
+	}
+
+	if custErr := service.hideMessage(ctx, req); custErr != nil {
+		return custErr
+	}
+
+	if err := service.SearchIndex.Hide(ctx, req.MessageID, req.UserID); err != nil {
+		log.Printf("error hiding message %d in search index: %v", req.MessageID, err)
+	}
+
+	service.Notifier.Notify([]uint64{req.UserID}, EventMessageDeleted, req.MatchID, &params.MessageDeletedResponse{
+		MessageID: req.MessageID,
+		MatchID:   req.MatchID,
+	})
+	return nil
+}
+
+func (service *MessageServiceImpl) hideMessage(ctx context.Context, req *params.MessageDeleteRequest) *response.CustomError {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	_, message, custErr := service.findMessage(ctx, tx, req.MatchID, req.MessageID, req.UserID)
+	if custErr != nil {
+		return custErr
+	}
+
+	err = service.MessageRepository.HideMessage(ctx, tx, message.Id, req.UserID, time.Now())
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+	return nil
+}
+
+const defaultSearchPageSize = 20
+
+// SearchMessages finds messages by content across every match the caller
+// belongs to, newest first, with the matching words highlighted.
+func (service *MessageServiceImpl) SearchMessages(ctx context.Context, req *params.MessageSearchRequest) (*params.MessageSearchResponse, *response.CustomError) {
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
+	terms := search.Terms(req.Query)
+	if len(terms) == 0 {
+		return nil, response.BadRequestErrorWithAdditionalInfo("Search query has no words.")
+	}
+
+	page, limit := req.Page, req.Limit
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = defaultSearchPageSize
+	}
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	matches, err := service.MatchRepository.FindAllMatchByUserID(ctx, tx, req.UserID)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	var matchIDs []uint64
+	for _, match := range matches {
+		matchIDs = append(matchIDs, match.Id)
+	}
+
+	// One extra hit tells us whether another page exists.
+	found, err := service.SearchIndex.Search(ctx, search.Query{
+		ViewerID: req.UserID,
+		MatchIDs: matchIDs,
+		Text:     req.Query,
+		Offset:   (page - 1) * limit,
+		Limit:    limit + 1,
+	})
+	if err != nil {
+		log.Printf("error searching messages for user %d: %v", req.UserID, err)
+		return nil, response.GeneralError("Failed searching messages")
+	}
+
+	var result = &params.MessageSearchResponse{
+		Query:   req.Query,
+		Page:    page,
+		Limit:   limit,
+		Results: []*params.MessageSearchResult{},
+	}
+	if len(found) > limit {
+		result.HasMore = true
+		found = found[:limit]
+	}
+
+	for _, doc := range found {
+		result.Results = append(result.Results, &params.MessageSearchResult{
+			MessageID: doc.MessageID,
+			MatchID:   doc.MatchID,
+			SenderID:  doc.SenderID,
+			Snippet:   search.Highlight(doc.Content, terms),
+			SentAt:    doc.SentAt,
 		})
 	}
+	return result, nil
+}
+
+// indexMessage keeps the search index in step with a written message. A failure
+// only makes the message harder to find, so it does not fail the write.
+func (service *MessageServiceImpl) indexMessage(ctx context.Context, message *models.Message) {
+	if message.Type != models.MessageTypeText || message.Content == "" {
+		return
+	}
 
+	err := service.SearchIndex.Index(ctx, search.Document{
+		MessageID:   message.Id,
+		MatchID:     message.MatchID,
+		SenderID:    message.SenderID,
+		Content:     message.Content,
+		SentAt:      message.SendAt,
+		ExpireAfter: time.Duration(message.ExpireAfter.Int64) * time.Second,
+		ExpiresAt:   message.ExpiresAt.Time,
+	})
+	if err != nil {
+		log.Printf("error indexing message %d: %v", message.Id, err)
+	}
+}
+
+// findMessage loads a message of a match the user belongs to.
+func (service *MessageServiceImpl) findMessage(ctx context.Context, tx *sql.Tx, matchID, messageID, userID uint64) (*models.Match, *models.Message, *response.CustomError) {
+	match, err := service.MatchRepository.FindMatchByID(ctx, tx, matchID)
+	if err != nil || !match.HasMember(userID) {
+		return nil, nil, response.NotFoundError("Match not found.")
+	}
+
+	message, err := service.MessageRepository.FindMessageByID(ctx, tx, messageID)
+	if err != nil || message.MatchID != match.Id {
+		return nil, nil, response.NotFoundError("Message not found.")
+	}
+	return match, message, nil
+}
+
+// findOwnMessage is findMessage for changes only the sender may make to a
+// message they wrote and have not unsent yet.
+func (service *MessageServiceImpl) findOwnMessage(ctx context.Context, tx *sql.Tx, matchID, messageID, userID uint64) (*models.Match, *models.Message, *response.CustomError) {
+	match, message, custErr := service.findMessage(ctx, tx, matchID, messageID, userID)
+	if custErr != nil {
+		return nil, nil, custErr
+	}
+	if message.SenderID != userID || message.Type == models.MessageTypeSystem || message.DeletedAt.Valid {
+		return nil, nil, response.NotFoundError("Message not found.")
+	}
+	return match, message, nil
+}
+
+// toMessageResponses converts messages and attaches their attachment metadata
+// and grouped reactions with a single lookup each.
+func (service *MessageServiceImpl) toMessageResponses(ctx context.Context, tx *sql.Tx, messages []*models.Message) ([]*params.MessageResponse, error) {
+	var attachmentIDs, messageIDs []uint64
+	for _, msg := range messages {
+		messageIDs = append(messageIDs, msg.Id)
+		if msg.AttachmentID.Valid {
+			attachmentIDs = append(attachmentIDs, uint64(msg.AttachmentID.Int64))
+		}
+	}
+
+	attachments, err := service.AttachmentRepository.FindAttachmentsByIDs(ctx, tx, attachmentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint64]*models.Attachment)
+	for _, attachment := range attachments {
+		byID[attachment.Id] = attachment
+	}
+
+	reactions, err := service.ReactionRepository.FindReactionsByMessageIDs(ctx, tx, messageIDs)
+	if err != nil {
+		return nil, err
+	}
+	reactionsByMessage := groupReactions(reactions)
+
+	result := []*params.MessageResponse{}
+	for _, msg := range messages {
+		res := toMessageResponse(msg)
+		if attachment, ok := byID[uint64(msg.AttachmentID.Int64)]; ok && msg.AttachmentID.Valid {
+			res.Attachment = toAttachmentResponse(attachment)
+		}
+		if groups, ok := reactionsByMessage[msg.Id]; ok {
+			res.Reactions = groups
+		}
+		result = append(result, res)
+	}
 	return result, nil
 }
+
+func toMessageResponse(msg *models.Message) *params.MessageResponse {
+	return &params.MessageResponse{
+		Id:              msg.Id,
+		MatchID:         msg.MatchID,
+		SenderID:        msg.SenderID,
+		Type:            msg.Type,
+		ClientMessageID: msg.ClientMessageID.String,
+		Content:         msg.Content,
+		SendAt:          msg.SendAt,
+		DeliveredAt:     nullTimePtr(msg.DeliveredAt),
+		ReadAt:          nullTimePtr(msg.ReadAt),
+		EditedAt:        nullTimePtr(msg.EditedAt),
+		DeletedAt:       nullTimePtr(msg.DeletedAt),
+		ExpiresAt:       nullTimePtr(msg.ExpiresAt),
+		Reactions:       []*params.ReactionGroup{},
+	}
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"sweatsparks/internal/models"
+	"sweatsparks/internal/repositories"
+)
+
+// Realtime events the services push to connected clients themselves, for
+// changes that do not only come in over a WebSocket connection.
+const (
+	EventMessageNew     = "message.new"
+	EventMessageEdited  = "message.edited"
+	EventMessageUnsent  = "message.unsent"
+	EventMessageDeleted = "message.deleted"
+	EventMessageExpired = "message.expired"
+
+	EventMatchClosed = "match.closed"
+
+	EventReactionAdded   = "reaction.added"
+	EventReactionRemoved = "reaction.removed"
+)
+
+// Notifier delivers a realtime event to every open connection of the given
+// users. The websocket hub implements it.
+type Notifier interface {
+	Notify(userIDs []uint64, eventType string, matchID uint64, payload interface{})
+}
+
+// SessionCloser drops every open connection that belongs to one of the given
+// sessions of a user, once those sessions are revoked. The websocket hub
+// implements it.
+type SessionCloser interface {
+	CloseSessions(userID uint64, sessionIDs []uint64)
+}
+
+// ShadowBanUpdater applies a shadow-ban, or its lifting, to the connections a
+// user already has open. The websocket hub implements it.
+type ShadowBanUpdater interface {
+	UpdateShadowBan(userID uint64, shadowBanned bool)
+}
+
+// matchRecipients lists who hears about a change actorID made in match. The
+// changes of a shadow-banned user only ever reach that user.
+func matchRecipients(ctx context.Context, tx *sql.Tx, users repositories.UserRepository, match *models.Match, actorID uint64) ([]uint64, error) {
+	actor, err := users.FindUserById(ctx, tx, int(actorID))
+	if err != nil {
+		return nil, err
+	}
+	if actor.IsShadowBanned() {
+		return []uint64{actorID}, nil
+	}
+	return []uint64{match.UserOne, match.UserTwo}, nil
+}
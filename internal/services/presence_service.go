@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/repositories"
+	"sweatsparks/pkg/helpers"
+	"sweatsparks/pkg/presence"
+	"sync"
+	"time"
+)
+
+// PresenceService keeps track of which users currently hold a WebSocket
+// connection. Online state is shared by every instance through a
+// presence.Store; last_seen_at is written to the users table when a user's
+// last connection anywhere goes away.
+type PresenceService interface {
+	SetOnline(userID uint64)
+	SetOffline(userID uint64)
+	GetPresence(ctx context.Context, viewerID int, userIDs []uint64) ([]*params.PresenceResponse, *response.CustomError)
+	Run()
+}
+
+type PresenceServiceImpl struct {
+	MySqlDB         *sql.DB
+	UserRepository  repositories.UserRepository
+	MatchRepository repositories.MatchRepository
+	Store           presence.Store
+
+	// local holds the users connected to this instance, and pending the
+	// changes to it that Run has not written to Store yet.
+	mu      sync.Mutex
+	local   map[uint64]bool
+	pending map[uint64]bool
+	wake    chan struct{}
+}
+
+func NewPresenceService(db *sql.DB, userRepository repositories.UserRepository, matchRepository repositories.MatchRepository, store presence.Store) PresenceService {
+	return &PresenceServiceImpl{
+		MySqlDB:         db,
+		UserRepository:  userRepository,
+		MatchRepository: matchRepository,
+		Store:           store,
+		local:           make(map[uint64]bool),
+		pending:         make(map[uint64]bool),
+		wake:            make(chan struct{}, 1),
+	}
+}
+
+// SetOnline and SetOffline are called from the hub loop, so they only queue
+// the change for Run.
+func (service *PresenceServiceImpl) SetOnline(userID uint64) {
+	service.mu.Lock()
+	service.local[userID] = true
+	service.pending[userID] = true
+	service.mu.Unlock()
+
+	service.signal()
+}
+
+func (service *PresenceServiceImpl) SetOffline(userID uint64) {
+	service.mu.Lock()
+	delete(service.local, userID)
+	service.pending[userID] = false
+	service.mu.Unlock()
+
+	service.signal()
+}
+
+func (service *PresenceServiceImpl) signal() {
+	select {
+	case service.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run writes queued presence changes to the store and re-adds this
+// instance's users before their claims expire.
+func (service *PresenceServiceImpl) Run() {
+	ticker := time.NewTicker(presence.TTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-service.wake:
+			service.flush()
+		case <-ticker.C:
+			service.refresh()
+		}
+	}
+}
+
+func (service *PresenceServiceImpl) flush() {
+	service.mu.Lock()
+	changes := service.pending
+	service.pending = make(map[uint64]bool)
+	service.mu.Unlock()
+
+	ctx := context.Background()
+	for userID, online := range changes {
+		if online {
+			if err := service.Store.Add(ctx, userID); err != nil {
+				log.Printf("error saving presence for user %d: %v", userID, err)
+			}
+			continue
+		}
+
+		stillOnline, err := service.Store.Remove(ctx, userID)
+		if err != nil {
+			log.Printf("error saving presence for user %d: %v", userID, err)
+			continue
+		}
+		if !stillOnline {
+			service.saveLastSeen(userID, time.Now())
+		}
+	}
+}
+
+func (service *PresenceServiceImpl) refresh() {
+	service.mu.Lock()
+	userIDs := make([]uint64, 0, len(service.local))
+	for userID := range service.local {
+		userIDs = append(userIDs, userID)
+	}
+	service.mu.Unlock()
+
+	if len(userIDs) == 0 {
+		return
+	}
+	if err := service.Store.Add(context.Background(), userIDs...); err != nil {
+		log.Printf("error refreshing presence: %v", err)
+	}
+}
+
+func (service *PresenceServiceImpl) saveLastSeen(userID uint64, at time.Time) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		log.Printf("error saving last seen for user %d: %v", userID, err)
+		return
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	if err := service.UserRepository.UpdateLastSeen(context.Background(), tx, userID, at); err != nil {
+		log.Printf("error saving last seen for user %d: %v", userID, err)
+	}
+}
+
+// GetPresence returns presence for the requested users the viewer is matched
+// with. Anyone else is left out of the result rather than reported offline.
+func (service *PresenceServiceImpl) GetPresence(ctx context.Context, viewerID int, userIDs []uint64) ([]*params.PresenceResponse, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	matches, err := service.MatchRepository.FindAllMatchByUserID(ctx, tx, uint64(viewerID))
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	matched := make(map[uint64]bool)
+	for _, match := range matches {
+		if match.UserOne == uint64(viewerID) {
+			matched[match.UserTwo] = true
+		} else {
+			matched[match.UserOne] = true
+		}
+	}
+
+	var visible []uint64
+	for _, id := range userIDs {
+		if matched[id] {
+			visible = append(visible, id)
+		}
+	}
+
+	users, err := service.UserRepository.FindUsersByIDs(ctx, tx, visible)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	online, err := service.Store.Online(ctx, visible)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	var result []*params.PresenceResponse
+	for _, user := range users {
+		result = append(result, &params.PresenceResponse{
+			UserID:     user.Id,
+			Online:     online[user.Id],
+			LastSeenAt: nullTimePtr(user.LastSeenAt),
+		})
+	}
+
+	return result, nil
+}
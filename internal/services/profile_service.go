@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/middleware"
 	"sweatsparks/internal/models"
 	"sweatsparks/internal/params"
 	"sweatsparks/internal/repositories"
@@ -15,24 +16,35 @@ import (
 
 type ProfileService interface {
 	CreateProfileUser(ctx context.Context, req *params.ProfileRequest) (*params.ProfileResponse, *response.CustomError)
-	GetProfileUser(ctx context.Context, userID int) (*params.ProfileResponse, *response.CustomError)
-	GetAllProfileUser(ctx context.Context, userID int, gender, location string) ([]*params.ProfileResponse, *response.CustomError)
+	GetProfileUser(ctx context.Context, viewerID, userID int) (*params.ProfileResponse, *response.CustomError)
+	GetProfileForModeration(ctx context.Context, userID int) (*params.AdminProfileResponse, *response.CustomError)
+	GetAllProfileUser(ctx context.Context, viewerID int, gender, location string) ([]*params.ProfileResponse, *response.CustomError)
 	UpdateProfileUser(ctx context.Context, req *params.ProfileRequest) (*params.ProfileResponse, *response.CustomError)
 }
 
 type ProfileServiceImpl struct {
 	MySqlDB           *sql.DB
 	ProfileRepository repositories.ProfileRepository
+	BlockRepository   repositories.BlockRepository
+	UserRepository    repositories.UserRepository
 }
 
-func NewProfileService(db *sql.DB, profileRepository repositories.ProfileRepository) ProfileService {
+func NewProfileService(db *sql.DB, profileRepository repositories.ProfileRepository, blockRepository repositories.BlockRepository, userRepository repositories.UserRepository) ProfileService {
 	return &ProfileServiceImpl{
 		MySqlDB:           db,
 		ProfileRepository: profileRepository,
+		BlockRepository:   blockRepository,
+		UserRepository:    userRepository,
 	}
 }
 
 func (service *ProfileServiceImpl) CreateProfileUser(ctx context.Context, req *params.ProfileRequest) (*params.ProfileResponse, *response.CustomError) {
+	userID, custErr := middleware.AuthorizeClaimedUser(ctx, req.UserID)
+	if custErr != nil {
+		return nil, custErr
+	}
+	req.UserID = userID
+
 	val := validator.New()
 	err := val.Struct(req)
 	if err != nil {
@@ -95,13 +107,26 @@ func (service *ProfileServiceImpl) CreateProfileUser(ctx context.Context, req *p
 	}, nil
 }
 
-func (service *ProfileServiceImpl) GetProfileUser(ctx context.Context, userID int) (*params.ProfileResponse, *response.CustomError) {
+func (service *ProfileServiceImpl) GetProfileUser(ctx context.Context, viewerID, userID int) (*params.ProfileResponse, *response.CustomError) {
 	tx, err := service.MySqlDB.Begin()
 	if err != nil {
 		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
 	}
 	defer helpers.CommitOrRollback(tx)
 
+	blocked, err := service.BlockRepository.IsBlocked(ctx, tx, uint64(viewerID), uint64(userID))
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+	if blocked {
+		return nil, response.BadRequestErrorWithAdditionalInfo("Profile not found.")
+	}
+
+	user, err := service.UserRepository.FindUserById(ctx, tx, userID)
+	if err != nil || (viewerID != userID && user.IsHidden(time.Now())) {
+		return nil, response.BadRequestErrorWithAdditionalInfo("Profile not found.")
+	}
+
 	result, err := service.ProfileRepository.FindProfileByUserID(ctx, tx, userID)
 	if err != nil {
 		return nil, response.BadRequestErrorWithAdditionalInfo("Profile not found.")
@@ -120,19 +145,70 @@ func (service *ProfileServiceImpl) GetProfileUser(ctx context.Context, userID in
 	}, nil
 }
 
-func (service *ProfileServiceImpl) GetAllProfileUser(ctx context.Context, userID int, gender, location string) ([]*params.ProfileResponse, *response.CustomError) {
+// GetProfileForModeration shows a profile with its photos and account state,
+// whether or not other users can see it.
+func (service *ProfileServiceImpl) GetProfileForModeration(ctx context.Context, userID int) (*params.AdminProfileResponse, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	user, err := service.UserRepository.FindUserById(ctx, tx, userID)
+	if err != nil {
+		return nil, response.NotFoundError("User not found.")
+	}
+
+	result, err := service.ProfileRepository.FindProfileByUserID(ctx, tx, userID)
+	if err != nil {
+		return nil, response.NotFoundError("Profile not found.")
+	}
+
+	photos, err := service.ProfileRepository.FindPhotosByUserID(ctx, tx, uint64(userID))
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	var profile = &params.AdminProfileResponse{
+		ProfileResponse: params.ProfileResponse{
+			UserID:           result.UserID,
+			FirstName:        result.FirstName,
+			LastName:         result.LastName,
+			Gender:           result.Gender,
+			GenderPreference: result.GenderPreference,
+			BirthDate:        result.BirthDate,
+			Bio:              result.Bio,
+			Location:         result.Location,
+			Interest:         result.Interest,
+		},
+		Hidden: user.IsHidden(time.Now()),
+		User:   toUserResponse(user),
+		Photos: []*params.AdminPhotoResponse{},
+	}
+	for _, photo := range photos {
+		profile.Photos = append(profile.Photos, &params.AdminPhotoResponse{
+			Id:         photo.Id,
+			URL:        photo.URL,
+			IsPrimary:  photo.IsPrimary,
+			UploadedAt: photo.UploadedAt,
+		})
+	}
+	return profile, nil
+}
+
+func (service *ProfileServiceImpl) GetAllProfileUser(ctx context.Context, viewerID int, gender, location string) ([]*params.ProfileResponse, *response.CustomError) {
 	tx, err := service.MySqlDB.Begin()
 	if err != nil {
 		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
 	}
 	defer helpers.CommitOrRollback(tx)
 
-	results, err := service.ProfileRepository.FindAllProfileByLocationGender(ctx, tx, location, gender)
+	results, err := service.ProfileRepository.FindAllProfileByLocationGender(ctx, tx, uint64(viewerID), location, gender)
 	if err != nil {
 		return nil, response.BadRequestErrorWithAdditionalInfo("Profile not found.")
 	}
 
-	var responses []*params.ProfileResponse
+	responses := []*params.ProfileResponse{}
 	for _, result := range results {
 		var profile = new(params.ProfileResponse)
 		profile.UserID = result.UserID
@@ -144,12 +220,19 @@ func (service *ProfileServiceImpl) GetAllProfileUser(ctx context.Context, userID
 		profile.Bio = result.Bio
 		profile.Location = result.Location
 		profile.Interest = result.Interest
+		responses = append(responses, profile)
 	}
 
 	return responses, nil
 }
 
 func (service *ProfileServiceImpl) UpdateProfileUser(ctx context.Context, req *params.ProfileRequest) (*params.ProfileResponse, *response.CustomError) {
+	userID, custErr := middleware.AuthorizeClaimedUser(ctx, req.UserID)
+	if custErr != nil {
+		return nil, custErr
+	}
+	req.UserID = userID
+
 	val := validator.New()
 	err := val.Struct(req)
 	if err != nil {
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/middleware"
+	"sweatsparks/internal/models"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/repositories"
+	"sweatsparks/pkg/helpers"
+	"time"
+
+	"github.com/go-playground/validator"
+)
+
+// ReactionEmojis is the set of emoji a message can be reacted with.
+var ReactionEmojis = []string{"❤️", "😂", "😮", "😢", "😡", "👍", "🔥"}
+
+type ReactionService interface {
+	AddReaction(ctx context.Context, req *params.ReactionRequest) (*params.ReactionResponse, *response.CustomError)
+	RemoveReaction(ctx context.Context, req *params.ReactionRequest) *response.CustomError
+}
+
+type ReactionServiceImpl struct {
+	MySqlDB            *sql.DB
+	ReactionRepository repositories.ReactionRepository
+	MessageRepository  repositories.MessageRepository
+	MatchRepository    repositories.MatchRepository
+	UserRepository     repositories.UserRepository
+	Notifier           Notifier
+}
+
+func NewReactionService(db *sql.DB, reactionRepository repositories.ReactionRepository, messageRepository repositories.MessageRepository, matchRepository repositories.MatchRepository, userRepository repositories.UserRepository, notifier Notifier) ReactionService {
+	return &ReactionServiceImpl{
+		MySqlDB:            db,
+		ReactionRepository: reactionRepository,
+		MessageRepository:  messageRepository,
+		MatchRepository:    matchRepository,
+		UserRepository:     userRepository,
+		Notifier:           notifier,
+	}
+}
+
+// AddReaction sets the caller's reaction on a message, replacing the one they
+// had. Replacing is pushed to both participants as a removal of the old emoji
+// followed by the new one.
+func (service *ReactionServiceImpl) AddReaction(ctx context.Context, req *params.ReactionRequest) (*params.ReactionResponse, *response.CustomError) {
+	if custErr := middleware.AuthorizeUser(ctx, req.UserID); custErr != nil {
+		return nil, custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil || !isReactionEmoji(req.Emoji) {
+		return nil, response.BadRequestError()
+	}
+
+	result, previous, recipients, custErr := service.saveReaction(ctx, req)
+	if custErr != nil {
+		return nil, custErr
+	}
+	if recipients == nil {
+		return result, nil
+	}
+
+	if previous != nil {
+		service.Notifier.Notify(recipients, EventReactionRemoved, result.MatchID, &params.ReactionResponse{
+			MessageID: result.MessageID,
+			MatchID:   result.MatchID,
+			UserID:    req.UserID,
+			Emoji:     previous.Emoji,
+			At:        result.At,
+		})
+	}
+	service.Notifier.Notify(recipients, EventReactionAdded, result.MatchID, result)
+	return result, nil
+}
+
+// saveReaction stores the reaction and returns the one it replaced. It
+// returns no recipients when the caller already had the same reaction.
+func (service *ReactionServiceImpl) saveReaction(ctx context.Context, req *params.ReactionRequest) (*params.ReactionResponse, *models.Reaction, []uint64, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, nil, nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	match, message, custErr := service.findMessage(ctx, tx, req)
+	if custErr != nil {
+		return nil, nil, nil, custErr
+	}
+	recipients, err := matchRecipients(ctx, tx, service.UserRepository, match, req.UserID)
+	if err != nil {
+		return nil, nil, nil, response.GeneralError(err.Error())
+	}
+
+	now := time.Now()
+	result := &params.ReactionResponse{
+		MessageID: message.Id,
+		MatchID:   match.Id,
+		UserID:    req.UserID,
+		Emoji:     req.Emoji,
+		At:        now,
+	}
+
+	previous, err := service.ReactionRepository.FindReaction(ctx, tx, message.Id, req.UserID)
+	if err == nil && previous.Emoji == req.Emoji {
+		return result, nil, nil, nil
+	}
+
+	err = service.ReactionRepository.SaveReaction(ctx, tx, &models.Reaction{
+		MessageID: message.Id,
+		UserID:    req.UserID,
+		Emoji:     req.Emoji,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, nil, nil, response.GeneralError(err.Error())
+	}
+	return result, previous, recipients, nil
+}
+
+func (service *ReactionServiceImpl) RemoveReaction(ctx context.Context, req *params.ReactionRequest) *response.CustomError {
+	if custErr := middleware.AuthorizeUser(ctx, req.UserID); custErr != nil {
+		return custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return response.BadRequestError()
+	}
+
+	reaction, recipients, custErr := service.deleteReaction(ctx, req)
+	if custErr != nil {
+		return custErr
+	}
+
+	service.Notifier.Notify(recipients, EventReactionRemoved, req.MatchID, &params.ReactionResponse{
+		MessageID: reaction.MessageID,
+		MatchID:   req.MatchID,
+		UserID:    req.UserID,
+		Emoji:     reaction.Emoji,
+		At:        time.Now(),
+	})
+	return nil
+}
+
+func (service *ReactionServiceImpl) deleteReaction(ctx context.Context, req *params.ReactionRequest) (*models.Reaction, []uint64, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	match, message, custErr := service.findMessage(ctx, tx, req)
+	if custErr != nil {
+		return nil, nil, custErr
+	}
+
+	reaction, err := service.ReactionRepository.FindReaction(ctx, tx, message.Id, req.UserID)
+	if err != nil {
+		return nil, nil, response.NotFoundError("Reaction not found.")
+	}
+
+	_, err = service.ReactionRepository.DeleteReaction(ctx, tx, message.Id, req.UserID)
+	if err != nil {
+		return nil, nil, response.GeneralError(err.Error())
+	}
+
+	recipients, err := matchRecipients(ctx, tx, service.UserRepository, match, req.UserID)
+	if err != nil {
+		return nil, nil, response.GeneralError(err.Error())
+	}
+	return reaction, recipients, nil
+}
+
+// findMessage loads a message that can still be reacted to, in a match the
+// caller belongs to.
+func (service *ReactionServiceImpl) findMessage(ctx context.Context, tx *sql.Tx, req *params.ReactionRequest) (*models.Match, *models.Message, *response.CustomError) {
+	match, err := service.MatchRepository.FindMatchByID(ctx, tx, req.MatchID)
+	if err != nil || !match.HasMember(req.UserID) {
+		return nil, nil, response.NotFoundError("Match not found.")
+	}
+
+	message, err := service.MessageRepository.FindMessageByID(ctx, tx, req.MessageID)
+	if err != nil || message.MatchID != match.Id || message.DeletedAt.Valid {
+		return nil, nil, response.NotFoundError("Message not found.")
+	}
+	return match, message, nil
+}
+
+func isReactionEmoji(emoji string) bool {
+	for _, allowed := range ReactionEmojis {
+		if emoji == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// groupReactions groups reactions per message and emoji, keeping the order in
+// which each emoji was first used.
+func groupReactions(reactions []*models.Reaction) map[uint64][]*params.ReactionGroup {
+	grouped := make(map[uint64][]*params.ReactionGroup)
+	for _, reaction := range reactions {
+		var group *params.ReactionGroup
+		for _, existing := range grouped[reaction.MessageID] {
+			if existing.Emoji == reaction.Emoji {
+				group = existing
+				break
+			}
+		}
+		if group == nil {
+			group = &params.ReactionGroup{Emoji: reaction.Emoji, UserIDs: []uint64{}}
+			grouped[reaction.MessageID] = append(grouped[reaction.MessageID], group)
+		}
+		group.Count++
+		group.UserIDs = append(group.UserIDs, reaction.UserID)
+	}
+	return grouped
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/middleware"
+	"sweatsparks/internal/models"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/repositories"
+	"sweatsparks/pkg/helpers"
+	"time"
+
+	"github.com/go-playground/validator"
+)
+
+type ReportService interface {
+	CreateReport(ctx context.Context, req *params.ReportRequest) (*params.ReportSubmittedResponse, *response.CustomError)
+	GetReports(ctx context.Context, req *params.ReportListRequest) ([]*params.ReportResponse, *response.CustomError)
+	GetReport(ctx context.Context, reportID uint64) (*params.ReportResponse, *response.CustomError)
+	ReviewReport(ctx context.Context, req *params.ReportReviewRequest) (*params.ReportResponse, *response.CustomError)
+}
+
+type ReportServiceImpl struct {
+	MySqlDB           *sql.DB
+	ReportRepository  repositories.ReportRepository
+	ProfileRepository repositories.ProfileRepository
+	MessageRepository repositories.MessageRepository
+	MatchRepository   repositories.MatchRepository
+}
+
+func NewReportService(db *sql.DB, reportRepository repositories.ReportRepository, profileRepository repositories.ProfileRepository, messageRepository repositories.MessageRepository, matchRepository repositories.MatchRepository) ReportService {
+	return &ReportServiceImpl{
+		MySqlDB:           db,
+		ReportRepository:  reportRepository,
+		ProfileRepository: profileRepository,
+		MessageRepository: messageRepository,
+		MatchRepository:   matchRepository,
+	}
+}
+
+// profileSnapshot, photoSnapshot and messageSnapshot are what a report keeps
+// of its target, so moderators see the content as it was reported.
+type profileSnapshot struct {
+	UserID    uint64          `json:"user_id"`
+	FirstName string          `json:"first_name"`
+	LastName  string          `json:"last_name"`
+	Bio       string          `json:"bio"`
+	Location  string          `json:"location"`
+	Interests json.RawMessage `json:"interests"`
+}
+
+type photoSnapshot struct {
+	PhotoID    uint64    `json:"photo_id"`
+	UserID     uint64    `json:"user_id"`
+	URL        string    `json:"url"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+type messageSnapshot struct {
+	MessageID    uint64    `json:"message_id"`
+	MatchID      uint64    `json:"match_id"`
+	SenderID     uint64    `json:"sender_id"`
+	Content      string    `json:"content"`
+	AttachmentID *uint64   `json:"attachment_id,omitempty"`
+	SentAt       time.Time `json:"sent_at"`
+}
+
+// CreateReport files a report against a profile, a photo or a message. A
+// message can only be reported by the other member of its match. A reporter
+// has at most one open report per target.
+func (service *ReportServiceImpl) CreateReport(ctx context.Context, req *params.ReportRequest) (*params.ReportSubmittedResponse, *response.CustomError) {
+	if custErr := middleware.AuthorizeUser(ctx, req.ReporterID); custErr != nil {
+		return nil, custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.InvalidReportError()
+	}
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	var report = new(models.Report)
+	report.ReporterID = req.ReporterID
+	report.TargetType = req.TargetType
+	report.TargetID = req.TargetID
+	report.Reason = req.Reason
+	report.Details = req.Details
+	report.Status = models.ReportStatusOpen
+	report.CreatedAt = time.Now()
+
+	var snapshot interface{}
+	switch req.TargetType {
+	case models.ReportTargetProfile:
+		profile, err := service.ProfileRepository.FindProfileByUserID(ctx, tx, int(req.TargetID))
+		if err != nil {
+			return nil, response.NotFoundError("Profile not found.")
+		}
+		report.ReportedUserID = profile.UserID
+		snapshot = &profileSnapshot{
+			UserID:    profile.UserID,
+			FirstName: profile.FirstName,
+			LastName:  profile.LastName,
+			Bio:       profile.Bio,
+			Location:  profile.Location,
+			Interests: profile.Interest,
+		}
+	case models.ReportTargetPhoto:
+		photo, err := service.ProfileRepository.FindPhotoByID(ctx, tx, req.TargetID)
+		if err != nil {
+			return nil, response.NotFoundError("Photo not found.")
+		}
+		report.ReportedUserID = photo.UserID
+		snapshot = &photoSnapshot{
+			PhotoID:    photo.Id,
+			UserID:     photo.UserID,
+			URL:        photo.URL,
+			UploadedAt: photo.UploadedAt,
+		}
+	case models.ReportTargetMessage:
+		message, err := service.MessageRepository.FindMessageByID(ctx, tx, req.TargetID)
+		if err != nil {
+			return nil, response.NotFoundError("Message not found.")
+		}
+		match, err := service.MatchRepository.FindMatchByID(ctx, tx, message.MatchID)
+		if err != nil || !match.HasMember(req.ReporterID) {
+			return nil, response.NotFoundError("Message not found.")
+		}
+		if message.Type != models.MessageTypeText || message.DeletedAt.Valid {
+			return nil, response.InvalidReportError("Message can not be reported.")
+		}
+		report.ReportedUserID = message.SenderID
+		snapshot = &messageSnapshot{
+			MessageID:    message.Id,
+			MatchID:      message.MatchID,
+			SenderID:     message.SenderID,
+			Content:      message.Content,
+			AttachmentID: nullableUint64(message.AttachmentID),
+			SentAt:       message.SendAt,
+		}
+	}
+
+	if report.ReportedUserID == req.ReporterID {
+		return nil, response.InvalidReportError("You can not report yourself.")
+	}
+
+	_, err = service.ReportRepository.FindOpenReport(ctx, tx, req.ReporterID, req.TargetType, req.TargetID)
+	if err == nil {
+		return nil, response.DuplicateReportError("You have already reported this.")
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	report.Snapshot, err = json.Marshal(snapshot)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	// The unique key catches a concurrent submission the check above missed.
+	err = service.ReportRepository.CreateReport(ctx, tx, report)
+	if errors.Is(err, repositories.ErrDuplicateReport) {
+		return nil, response.DuplicateReportError("You have already reported this.")
+	}
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	return &params.ReportSubmittedResponse{
+		Id:         report.Id,
+		TargetType: report.TargetType,
+		TargetID:   report.TargetID,
+		Reason:     report.Reason,
+		CreatedAt:  report.CreatedAt,
+	}, nil
+}
+
+const defaultReportPageSize = 50
+
+// GetReports lists the moderation queue for one status, oldest first.
+func (service *ReportServiceImpl) GetReports(ctx context.Context, req *params.ReportListRequest) ([]*params.ReportResponse, *response.CustomError) {
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
+	page, limit := req.Page, req.Limit
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = defaultReportPageSize
+	}
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	reports, err := service.ReportRepository.FindReportsByStatus(ctx, tx, req.Status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	result := []*params.ReportResponse{}
+	for _, report := range reports {
+		result = append(result, toReportResponse(report))
+	}
+	return result, nil
+}
+
+func (service *ReportServiceImpl) GetReport(ctx context.Context, reportID uint64) (*params.ReportResponse, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	report, err := service.ReportRepository.FindReportByID(ctx, tx, reportID)
+	if err != nil {
+		return nil, response.NotFoundError("Report not found.")
+	}
+	return toReportResponse(report), nil
+}
+
+// ReviewReport closes an open report as actioned or dismissed. A report is
+// reviewed once; a closed report is not reopened.
+func (service *ReportServiceImpl) ReviewReport(ctx context.Context, req *params.ReportReviewRequest) (*params.ReportResponse, *response.CustomError) {
+	if custErr := middleware.AuthorizeUser(ctx, req.ReviewerID); custErr != nil {
+		return nil, custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	report, err := service.ReportRepository.FindReportByID(ctx, tx, req.ReportID)
+	if err != nil {
+		return nil, response.NotFoundError("Report not found.")
+	}
+	if report.Status != models.ReportStatusOpen {
+		return nil, response.BadRequestErrorWithAdditionalInfo("Report has already been reviewed.")
+	}
+
+	now := time.Now()
+	err = service.ReportRepository.UpdateReportStatus(ctx, tx, report.Id, req.Status, req.ReviewerID, req.Note, now)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	report.Status = req.Status
+	report.ReviewerID = sql.NullInt64{Int64: int64(req.ReviewerID), Valid: true}
+	report.ReviewNote = sql.NullString{String: req.Note, Valid: true}
+	report.ReviewedAt = sql.NullTime{Time: now, Valid: true}
+	return toReportResponse(report), nil
+}
+
+func toReportResponse(report *models.Report) *params.ReportResponse {
+	var result = &params.ReportResponse{
+		Id:             report.Id,
+		ReporterID:     report.ReporterID,
+		ReportedUserID: report.ReportedUserID,
+		TargetType:     report.TargetType,
+		TargetID:       report.TargetID,
+		Reason:         report.Reason,
+		Details:        report.Details,
+		Snapshot:       report.Snapshot,
+		Status:         report.Status,
+		ReviewerID:     nullableUint64(report.ReviewerID),
+		CreatedAt:      report.CreatedAt,
+	}
+	if report.ReviewNote.Valid {
+		result.ReviewNote = &report.ReviewNote.String
+	}
+	if report.ReviewedAt.Valid {
+		result.ReviewedAt = &report.ReviewedAt.Time
+	}
+	return result
+}
+
+func nullableUint64(value sql.NullInt64) *uint64 {
+	if !value.Valid {
+		return nil
+	}
+	id := uint64(value.Int64)
+	return &id
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/middleware"
+	"sweatsparks/internal/models"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/repositories"
+	"sweatsparks/pkg/helpers"
+	"time"
+)
+
+type SessionService interface {
+	GetSessions(ctx context.Context) ([]*params.SessionResponse, *response.CustomError)
+	RevokeSession(ctx context.Context, sessionID uint64) *response.CustomError
+	RevokeOtherSessions(ctx context.Context) (*params.SessionsRevokedResponse, *response.CustomError)
+}
+
+type SessionServiceImpl struct {
+	MySqlDB           *sql.DB
+	SessionRepository repositories.SessionRepository
+	SessionCloser     SessionCloser
+	RefreshTokenTTL   time.Duration
+}
+
+func NewSessionService(db *sql.DB, sessionRepository repositories.SessionRepository, sessionCloser SessionCloser, refreshTokenTTL time.Duration) SessionService {
+	return &SessionServiceImpl{
+		MySqlDB:           db,
+		SessionRepository: sessionRepository,
+		SessionCloser:     sessionCloser,
+		RefreshTokenTTL:   refreshTokenTTL,
+	}
+}
+
+// GetSessions lists the caller's signed-in devices. A session unused for
+// longer than a refresh token lives can never be refreshed again, so it is
+// left out even though it was never revoked.
+func (service *SessionServiceImpl) GetSessions(ctx context.Context) ([]*params.SessionResponse, *response.CustomError) {
+	userID, custErr := middleware.ActingUserID(ctx)
+	if custErr != nil {
+		return nil, custErr
+	}
+	currentID, _ := middleware.SessionIDFromContext(ctx)
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	sessions, err := service.SessionRepository.FindActiveSessionsByUserID(ctx, tx, userID, time.Now().Add(-service.RefreshTokenTTL))
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	result := make([]*params.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, toSessionResponse(session, currentID))
+	}
+	return result, nil
+}
+
+// RevokeSession signs one of the caller's devices out. Revoking a session
+// that is already revoked is not an error.
+func (service *SessionServiceImpl) RevokeSession(ctx context.Context, sessionID uint64) *response.CustomError {
+	userID, custErr := middleware.ActingUserID(ctx)
+	if custErr != nil {
+		return custErr
+	}
+
+	revoked, custErr := service.revokeSession(ctx, userID, sessionID)
+	if custErr != nil {
+		return custErr
+	}
+	if revoked {
+		service.SessionCloser.CloseSessions(userID, []uint64{sessionID})
+	}
+	return nil
+}
+
+// revokeSession reports whether the session was still live before this call.
+func (service *SessionServiceImpl) revokeSession(ctx context.Context, userID, sessionID uint64) (bool, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return false, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	session, err := service.SessionRepository.FindSessionByID(ctx, tx, sessionID)
+	if err != nil || session.UserID != userID {
+		return false, response.NotFoundError("Session not found")
+	}
+	if session.RevokedAt.Valid {
+		return false, nil
+	}
+
+	err = service.SessionRepository.RevokeSession(ctx, tx, session.Id, time.Now())
+	if err != nil {
+		return false, response.GeneralError(err.Error())
+	}
+	return true, nil
+}
+
+// RevokeOtherSessions signs the caller out everywhere except the session the
+// request came with.
+func (service *SessionServiceImpl) RevokeOtherSessions(ctx context.Context) (*params.SessionsRevokedResponse, *response.CustomError) {
+	userID, custErr := middleware.ActingUserID(ctx)
+	if custErr != nil {
+		return nil, custErr
+	}
+	currentID, ok := middleware.SessionIDFromContext(ctx)
+	if !ok {
+		return nil, response.UnauthorizedError("Unauthorized")
+	}
+
+	revoked, custErr := service.revokeOtherSessions(ctx, userID, currentID)
+	if custErr != nil {
+		return nil, custErr
+	}
+	if len(revoked) > 0 {
+		service.SessionCloser.CloseSessions(userID, revoked)
+	}
+
+	return &params.SessionsRevokedResponse{Revoked: len(revoked)}, nil
+}
+
+func (service *SessionServiceImpl) revokeOtherSessions(ctx context.Context, userID, currentID uint64) ([]uint64, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	sessions, err := service.SessionRepository.FindActiveSessionsByUserID(ctx, tx, userID, time.Time{})
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	var revoked []uint64
+	for _, session := range sessions {
+		if session.Id != currentID {
+			revoked = append(revoked, session.Id)
+		}
+	}
+
+	err = service.SessionRepository.RevokeOtherSessions(ctx, tx, userID, currentID, time.Now())
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+	return revoked, nil
+}
+
+func toSessionResponse(session *models.Session, currentID uint64) *params.SessionResponse {
+	return &params.SessionResponse{
+		ID:          session.Id,
+		DeviceLabel: session.DeviceLabel,
+		UserAgent:   session.UserAgent,
+		IPAddress:   session.IPAddress,
+		CreatedAt:   session.CreatedAt,
+		LastUsedAt:  session.LastUsedAt,
+		Current:     session.Id == currentID,
+	}
+}
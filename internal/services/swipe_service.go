@@ -3,7 +3,9 @@ package services
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/middleware"
 	"sweatsparks/internal/models"
 	"sweatsparks/internal/params"
 	"sweatsparks/internal/repositories"
@@ -22,28 +24,63 @@ type SwipeService interface {
 type SwipeServiceImpl struct {
 	MySqlDB         *sql.DB
 	SwipeRepository repositories.SwipeRepository
+	MatchRepository repositories.MatchRepository
+	BlockRepository repositories.BlockRepository
+	UserRepository  repositories.UserRepository
+	EmailPolicy     *EmailPolicy
 }
 
-func NewSwipeService(db *sql.DB, swipeRepository repositories.SwipeRepository) SwipeService {
+func NewSwipeService(db *sql.DB, swipeRepository repositories.SwipeRepository, matchRepository repositories.MatchRepository, blockRepository repositories.BlockRepository, userRepository repositories.UserRepository, emailPolicy *EmailPolicy) SwipeService {
 	return &SwipeServiceImpl{
 		MySqlDB:         db,
 		SwipeRepository: swipeRepository,
+		MatchRepository: matchRepository,
+		BlockRepository: blockRepository,
+		UserRepository:  userRepository,
+		EmailPolicy:     emailPolicy,
 	}
 }
 
 func (service *SwipeServiceImpl) CreateSwipe(ctx context.Context, req *params.SwipeRequest) (*params.SwipeResponse, *response.CustomError) {
+	swiperID, custErr := middleware.AuthorizeClaimedUser(ctx, req.SwiperID)
+	if custErr != nil {
+		return nil, custErr
+	}
+	req.SwiperID = swiperID
+
 	val := validator.New()
 	err := val.Struct(req)
 	if err != nil {
 		return nil, response.BadRequestError()
 	}
 
+	if req.SwiperID == req.SwipeeID {
+		return nil, response.BadRequestErrorWithAdditionalInfo("Cannot swipe on yourself.")
+	}
+
 	tx, err := service.MySqlDB.Begin()
 	if err != nil {
 		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
 	}
 	defer helpers.CommitOrRollback(tx)
 
+	if custErr := service.EmailPolicy.requireVerified(ctx, tx, service.UserRepository, req.SwiperID, FeatureSwipe); custErr != nil {
+		return nil, custErr
+	}
+
+	blocked, err := service.BlockRepository.IsBlocked(ctx, tx, req.SwiperID, req.SwipeeID)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+	if blocked {
+		return nil, response.NotFoundError("User not found.")
+	}
+
+	swipee, err := service.UserRepository.FindUserById(ctx, tx, int(req.SwipeeID))
+	if err != nil || swipee.IsBanned() || swipee.IsSuspended(time.Now()) {
+		return nil, response.NotFoundError("User not found.")
+	}
+
 	var swipe = new(models.Swipe)
 	swipe.SwiperID = req.SwiperID
 	swipe.SwipeeID = req.SwipeeID
@@ -55,16 +92,76 @@ func (service *SwipeServiceImpl) CreateSwipe(ctx context.Context, req *params.Sw
 		return nil, response.GeneralError(err.Error())
 	}
 
-	return &params.SwipeResponse{
+	result := &params.SwipeResponse{
 		Id:        swipe.Id,
 		SwiperID:  swipe.SwiperID,
 		SwipeeID:  swipe.SwipeeID,
 		Direction: swipe.Direction,
 		SwipedAt:  swipe.SwipedAt,
-	}, nil
+	}
+
+	if swipe.Direction != models.SwipeDirectionRight {
+		return result, nil
+	}
+
+	// A shadow-banned user's swipes are kept but never lead to a match,
+	// whichever side of the swipe they are on.
+	swiper, err := service.UserRepository.FindUserById(ctx, tx, int(req.SwiperID))
+	if err != nil {
+		tx.Rollback()
+		return nil, response.GeneralError(err.Error())
+	}
+	if swiper.IsShadowBanned() || swipee.IsShadowBanned() {
+		return result, nil
+	}
+
+	// Roll the swipe back with the match so a retry can still create it;
+	// the deferred commit would otherwise keep a mutual swipe with no match.
+	match, err := service.createMatchOnMutualSwipe(ctx, tx, swipe)
+	if err != nil {
+		tx.Rollback()
+		return nil, response.GeneralError(err.Error())
+	}
+	if match != nil {
+		result.Matched = true
+		result.MatchID = match.Id
+	}
+
+	return result, nil
+}
+
+// createMatchOnMutualSwipe creates a match when the swipee has already swiped
+// right on the swiper. It returns nil when the swipe is not reciprocated.
+func (service *SwipeServiceImpl) createMatchOnMutualSwipe(ctx context.Context, tx *sql.Tx, swipe *models.Swipe) (*models.Match, error) {
+	reciprocal, err := service.SwipeRepository.FindSwipe(ctx, tx, int(swipe.SwipeeID), int(swipe.SwiperID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if reciprocal.Direction != models.SwipeDirectionRight {
+		return nil, nil
+	}
+
+	match := &models.Match{
+		UserOne:     swipe.SwipeeID,
+		UserTwo:     swipe.SwiperID,
+		MatchedTime: swipe.SwipedAt,
+	}
+	err = service.MatchRepository.CreateMatch(ctx, tx, match)
+	if err != nil {
+		return nil, err
+	}
+
+	return match, nil
 }
 
 func (service *SwipeServiceImpl) GetSwipeBySwiperAndSwipee(ctx context.Context, swiper, swipee int) (*params.SwipeResponse, *response.CustomError) {
+	if custErr := middleware.AuthorizeUser(ctx, uint64(swiper)); custErr != nil {
+		return nil, custErr
+	}
+
 	tx, err := service.MySqlDB.Begin()
 	if err != nil {
 		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
@@ -72,6 +169,9 @@ func (service *SwipeServiceImpl) GetSwipeBySwiperAndSwipee(ctx context.Context,
 	defer helpers.CommitOrRollback(tx)
 
 	result, err := service.SwipeRepository.FindSwipe(ctx, tx, swiper, swipee)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, response.NotFoundError("Swipe not found.")
+	}
 	if err != nil {
 		return nil, response.GeneralError(err.Error())
 	}
@@ -86,6 +186,10 @@ func (service *SwipeServiceImpl) GetSwipeBySwiperAndSwipee(ctx context.Context,
 }
 
 func (service *SwipeServiceImpl) GetAllSwipeeNotMatchBySwipee(ctx context.Context, swipee int) ([]*params.SwipeResponse, *response.CustomError) {
+	if custErr := middleware.AuthorizeUser(ctx, uint64(swipee)); custErr != nil {
+		return nil, custErr
+	}
+
 	tx, err := service.MySqlDB.Begin()
 	if err != nil {
 		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
@@ -3,12 +3,18 @@ package services
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
 	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/middleware"
 	"sweatsparks/internal/models"
 	"sweatsparks/internal/params"
 	"sweatsparks/internal/repositories"
+	"sweatsparks/internal/search"
 	"sweatsparks/pkg/encryption"
 	"sweatsparks/pkg/helpers"
+	"sweatsparks/pkg/mailer"
 	"sweatsparks/pkg/token"
 	"time"
 
@@ -18,73 +24,130 @@ import (
 type UserService interface {
 	RegisterUser(ctx context.Context, req *params.UserRegisterRequest) (*params.UserRegisterResponse, *response.CustomError)
 	LoginUser(ctx context.Context, req *params.UserLoginRequest) (*params.UserLoginResponse, *response.CustomError)
-	GetAllUser(ctx context.Context) ([]*params.GetAllUser, *response.CustomError)
+	RefreshToken(ctx context.Context, req *params.RefreshTokenRequest) (*params.UserLoginResponse, *response.CustomError)
+	Logout(ctx context.Context) *response.CustomError
+	VerifyEmail(ctx context.Context, req *params.VerifyEmailRequest) (*params.EmailVerificationResponse, *response.CustomError)
+	ResendVerificationEmail(ctx context.Context) *response.CustomError
+	SearchUsers(ctx context.Context, req *params.UserSearchRequest) ([]*params.GetAllUser, *response.CustomError)
+	ValidateToken(ctx context.Context, tokenStr string) (*params.AuthenticatedUser, *response.CustomError)
+	SuspendUser(ctx context.Context, req *params.ModerationRequest) (*params.GetAllUser, *response.CustomError)
+	UnsuspendUser(ctx context.Context, req *params.LiftModerationRequest) (*params.GetAllUser, *response.CustomError)
+	BanUser(ctx context.Context, req *params.ModerationRequest) (*params.GetAllUser, *response.CustomError)
+	UnbanUser(ctx context.Context, req *params.LiftModerationRequest) (*params.GetAllUser, *response.CustomError)
+	ShadowBanUser(ctx context.Context, req *params.ModerationRequest) (*params.GetAllUser, *response.CustomError)
+	UnshadowBanUser(ctx context.Context, req *params.LiftModerationRequest) (*params.GetAllUser, *response.CustomError)
+	UpdateUserRole(ctx context.Context, req *params.UpdateRoleRequest) (*params.GetAllUser, *response.CustomError)
 }
 
 type UserServiceImpl struct {
-	MySqlDB        *sql.DB
-	UserRepository repositories.UserRepository
+	MySqlDB           *sql.DB
+	UserRepository    repositories.UserRepository
+	SessionRepository repositories.SessionRepository
+	SessionCloser     SessionCloser
+	ShadowBans        ShadowBanUpdater
+	SearchIndex       search.Index
+	Tokens            *token.Keyring
+	Mailer            mailer.Mailer
+	AccessTokenTTL    time.Duration
+	RefreshTokenTTL   time.Duration
+	// EmailVerifyURL is the page the verification link opens, with the
+	// token added as the token query parameter.
+	EmailVerifyURL      string
+	EmailVerifyTTL      time.Duration
+	EmailVerifyCooldown time.Duration
 }
 
-func NeewUserService(mySql *sql.DB, userRepository repositories.UserRepository) UserService {
+func NeewUserService(mySql *sql.DB, userRepository repositories.UserRepository, sessionRepository repositories.SessionRepository, sessionCloser SessionCloser, shadowBans ShadowBanUpdater, searchIndex search.Index, tokens *token.Keyring, mail mailer.Mailer, accessTokenTTL, refreshTokenTTL time.Duration, emailVerifyURL string, emailVerifyTTL, emailVerifyCooldown time.Duration) UserService {
 	return &UserServiceImpl{
-		MySqlDB:        mySql,
-		UserRepository: userRepository,
+		MySqlDB:             mySql,
+		UserRepository:      userRepository,
+		SessionRepository:   sessionRepository,
+		SessionCloser:       sessionCloser,
+		ShadowBans:          shadowBans,
+		SearchIndex:         searchIndex,
+		Tokens:              tokens,
+		Mailer:              mail,
+		AccessTokenTTL:      accessTokenTTL,
+		RefreshTokenTTL:     refreshTokenTTL,
+		EmailVerifyURL:      emailVerifyURL,
+		EmailVerifyTTL:      emailVerifyTTL,
+		EmailVerifyCooldown: emailVerifyCooldown,
 	}
 }
 
+// RegisterUser creates the account and its first session, then mails the
+// verification link once both are committed.
 func (service *UserServiceImpl) RegisterUser(ctx context.Context, req *params.UserRegisterRequest) (*params.UserRegisterResponse, *response.CustomError) {
+	result, user, custErr := service.registerUser(ctx, req)
+	if custErr != nil {
+		return nil, custErr
+	}
+
+	// A failed send does not fail the registration; the user can ask for
+	// the email again once signed in.
+	if err := service.sendVerificationEmail(ctx, user); err != nil {
+		log.Printf("error sending verification email to user %d: %s", user.Id, err)
+	}
+	return result, nil
+}
+
+func (service *UserServiceImpl) registerUser(ctx context.Context, req *params.UserRegisterRequest) (*params.UserRegisterResponse, *models.User, *response.CustomError) {
 	val := validator.New()
 	err := val.Struct(req)
 	if err != nil {
-		return nil, response.BadRequestError()
+		return nil, nil, response.BadRequestError()
 	}
 
 	tx, err := service.MySqlDB.Begin()
 	if err != nil {
-		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+		return nil, nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
 	}
 	defer helpers.CommitOrRollback(tx)
 
 	_, err = service.UserRepository.FindUserByEmail(ctx, tx, req.Email)
 	if err == nil {
-		return nil, response.BadRequestErrorWithAdditionalInfo("Email has been registered.")
+		return nil, nil, response.BadRequestErrorWithAdditionalInfo("Email has been registered.")
 	}
 
 	_, err = service.UserRepository.FindUserByUsername(ctx, tx, req.Username)
 	if err == nil {
-		return nil, response.BadRequestErrorWithAdditionalInfo("Username has been taken.")
+		return nil, nil, response.BadRequestErrorWithAdditionalInfo("Username has been taken.")
 	}
 
 	var users = new(models.User)
 
 	passwordHash, err := encryption.HashPassword(req.Password)
 	if err != nil || passwordHash == "" {
-		return nil, response.GeneralErrorWithAdditionalInfo("Failed Hashing Password Errors: %s", err.Error())
+		return nil, nil, response.GeneralErrorWithAdditionalInfo("Failed Hashing Password Errors: %s", err.Error())
 	}
 
 	users.Email = req.Email
 	users.Username = req.Username
 	users.PasswordHash = passwordHash
+	users.Role = models.RoleUser
 	users.CreatedAt = time.Now()
 	users.UpdatedAt = time.Now()
+	users.EmailVerificationSentAt = sql.NullTime{Time: users.CreatedAt, Valid: true}
 
 	err = service.UserRepository.CreateUser(ctx, tx, users)
 	if err != nil {
-		return nil, response.GeneralError()
+		return nil, nil, response.GeneralError()
 	}
-	token, err := token.GenerateToken(int(users.Id))
-	if err != nil {
-		return nil, response.GeneralErrorWithAdditionalInfo("Failed Generate Token Errors: %s", err.Error())
+
+	tokens, custErr := service.startSession(ctx, tx, users, req.SessionDevice)
+	if custErr != nil {
+		return nil, nil, custErr
 	}
 
 	response := params.UserRegisterResponse{
-		Email:    users.Email,
-		Username: users.Username,
-		Token:    token,
+		Email:         users.Email,
+		Username:      users.Username,
+		Token:         tokens.Token,
+		RefreshToken:  tokens.RefreshToken,
+		EmailVerified: false,
 	}
 
-	return &response, nil
+	return &response, users, nil
 }
 
 func (service *UserServiceImpl) LoginUser(ctx context.Context, req *params.UserLoginRequest) (*params.UserLoginResponse, *response.CustomError) {
@@ -110,18 +173,316 @@ func (service *UserServiceImpl) LoginUser(ctx context.Context, req *params.UserL
 		return nil, response.BadRequestErrorWithAdditionalInfo("Password wrong")
 	}
 
-	token, err := token.GenerateToken(int(user.Id))
+	if custErr := accountStatusError(user); custErr != nil {
+		return nil, custErr
+	}
+
+	return service.startSession(ctx, tx, user, req.SessionDevice)
+}
+
+// RefreshToken spends a refresh token for a new access and refresh token pair
+// in the same session. A refresh token that was already spent means it has
+// leaked, so the whole session is revoked along with every token in it.
+func (service *UserServiceImpl) RefreshToken(ctx context.Context, req *params.RefreshTokenRequest) (*params.UserLoginResponse, *response.CustomError) {
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
+	result, revoked, custErr := service.refreshToken(ctx, req)
+	if revoked != nil {
+		service.SessionCloser.CloseSessions(revoked.UserID, []uint64{revoked.Id})
+	}
+	if custErr != nil {
+		return nil, custErr
+	}
+	return result, nil
+}
+
+// refreshToken returns the session it revoked when the token was reused, so
+// its sockets can be closed once the revocation has committed.
+func (service *UserServiceImpl) refreshToken(ctx context.Context, req *params.RefreshTokenRequest) (*params.UserLoginResponse, *models.Session, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	refreshToken, err := service.SessionRepository.FindRefreshTokenByHash(ctx, tx, token.HashRefreshToken(req.RefreshToken))
+	if err != nil {
+		return nil, nil, response.UnauthorizedError("Invalid refresh token")
+	}
+
+	session, err := service.SessionRepository.FindSessionByID(ctx, tx, refreshToken.SessionID)
+	if err != nil || session.RevokedAt.Valid {
+		return nil, nil, response.UnauthorizedError("Session has been revoked")
+	}
+
+	now := time.Now()
+	spent, err := service.SessionRepository.MarkRefreshTokenUsed(ctx, tx, refreshToken.Id, now)
+	if err != nil {
+		return nil, nil, response.GeneralError(err.Error())
+	}
+	if !spent {
+		err = service.SessionRepository.RevokeSession(ctx, tx, session.Id, now)
+		if err != nil {
+			return nil, nil, response.GeneralError(err.Error())
+		}
+		return nil, session, response.UnauthorizedError("Refresh token has already been used, session revoked")
+	}
+	if now.After(refreshToken.ExpiresAt) {
+		return nil, nil, response.UnauthorizedError("Refresh token expired")
+	}
+
+	user, err := service.UserRepository.FindUserById(ctx, tx, int(session.UserID))
+	if err != nil {
+		return nil, nil, response.UnauthorizedError("Invalid refresh token")
+	}
+	if custErr := accountStatusError(user); custErr != nil {
+		return nil, nil, custErr
+	}
+
+	if req.DeviceLabel != "" {
+		session.DeviceLabel = req.DeviceLabel
+	}
+	session.UserAgent = req.UserAgent
+	session.IPAddress = req.IPAddress
+	session.LastUsedAt = now
+	err = service.SessionRepository.TouchSession(ctx, tx, session)
+	if err != nil {
+		return nil, nil, response.GeneralError(err.Error())
+	}
+
+	result, custErr := service.issueTokens(ctx, tx, user, session.Id, refreshToken.Id)
+	return result, nil, custErr
+}
+
+// Logout revokes the session of the access token the request came with and
+// closes its WebSocket connections.
+func (service *UserServiceImpl) Logout(ctx context.Context) *response.CustomError {
+	userID, custErr := middleware.ActingUserID(ctx)
+	if custErr != nil {
+		return custErr
+	}
+	sessionID, ok := middleware.SessionIDFromContext(ctx)
+	if !ok {
+		return response.UnauthorizedError("Unauthorized")
+	}
+
+	if custErr := service.revokeSession(ctx, sessionID); custErr != nil {
+		return custErr
+	}
+	service.SessionCloser.CloseSessions(userID, []uint64{sessionID})
+	return nil
+}
+
+func (service *UserServiceImpl) revokeSession(ctx context.Context, sessionID uint64) *response.CustomError {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	err = service.SessionRepository.RevokeSession(ctx, tx, sessionID, time.Now())
+	if err != nil {
+		return response.GeneralError(err.Error())
+	}
+	return nil
+}
+
+// VerifyEmail marks the email a verification token was sent to as verified.
+// It needs no session, since the link is often opened on another device.
+func (service *UserServiceImpl) VerifyEmail(ctx context.Context, req *params.VerifyEmailRequest) (*params.EmailVerificationResponse, *response.CustomError) {
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
+	claims, err := service.Tokens.ValidateEmailToken(req.Token)
+	if err != nil {
+		return nil, response.BadRequestError("Invalid or expired verification token")
+	}
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	user, err := service.UserRepository.FindUserById(ctx, tx, claims.AuthId)
+	if err != nil || user.Email != claims.Email {
+		return nil, response.BadRequestError("Invalid or expired verification token")
+	}
+
+	if !user.EmailVerifiedAt.Valid {
+		now := time.Now()
+		err = service.UserRepository.VerifyEmail(ctx, tx, user.Id, now)
+		if err != nil {
+			return nil, response.GeneralError(err.Error())
+		}
+		user.EmailVerifiedAt = sql.NullTime{Time: now, Valid: true}
+	}
+
+	return &params.EmailVerificationResponse{
+		UserID:          user.Id,
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt.Time,
+	}, nil
+}
+
+// ResendVerificationEmail mails the caller a new verification link, at most
+// once per EmailVerifyCooldown. Links sent earlier stay valid. The send time
+// is only recorded once the email went out, so a failed send can be retried
+// straight away.
+func (service *UserServiceImpl) ResendVerificationEmail(ctx context.Context) *response.CustomError {
+	userID, custErr := middleware.ActingUserID(ctx)
+	if custErr != nil {
+		return custErr
+	}
+
+	now := time.Now()
+	user, custErr := service.findResendableUser(ctx, userID, now)
+	if custErr != nil {
+		return custErr
+	}
+
+	err := service.sendVerificationEmail(ctx, user)
+	if err != nil {
+		log.Printf("error sending verification email to user %d: %v", user.Id, err)
+		return response.GeneralError("Failed sending email")
+	}
+
+	if err := service.recordVerificationSent(ctx, user.Id, now); err != nil {
+		log.Printf("error recording verification email sent to user %d: %v", user.Id, err)
+	}
+	return nil
+}
+
+// findResendableUser returns the user if they are unverified and out of the
+// resend cooldown.
+func (service *UserServiceImpl) findResendableUser(ctx context.Context, userID uint64, now time.Time) (*models.User, *response.CustomError) {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	user, err := service.UserRepository.FindUserById(ctx, tx, int(userID))
+	if err != nil {
+		return nil, response.NotFoundError("User not found.")
+	}
+	if user.IsEmailVerified() {
+		return nil, response.BadRequestError("Email is already verified")
+	}
+
+	if user.EmailVerificationSentAt.Valid && now.Sub(user.EmailVerificationSentAt.Time) < service.EmailVerifyCooldown {
+		return nil, response.TooManyRequestsError("Verification email was sent recently, try again later")
+	}
+	return user, nil
+}
+
+func (service *UserServiceImpl) recordVerificationSent(ctx context.Context, userID uint64, sentAt time.Time) error {
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	return service.UserRepository.UpdateEmailVerificationSent(ctx, tx, userID, sentAt)
+}
+
+func (service *UserServiceImpl) sendVerificationEmail(ctx context.Context, user *models.User) error {
+	verifyToken, err := service.Tokens.GenerateEmailToken(int(user.Id), user.Email, service.EmailVerifyTTL)
+	if err != nil {
+		return err
+	}
+
+	link, err := url.Parse(service.EmailVerifyURL)
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", verifyToken)
+	link.RawQuery = query.Encode()
+
+	return service.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Sweatsparks email",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address by opening this link:\n\n%s\n\n"+
+			"The link expires in %s. If you did not sign up for Sweatsparks, you can ignore this email.\n",
+			user.Username, link.String(), service.EmailVerifyTTL),
+	})
+}
+
+// startSession opens a session for a user who just proved who they are and
+// issues its first tokens.
+func (service *UserServiceImpl) startSession(ctx context.Context, tx *sql.Tx, user *models.User, device params.SessionDevice) (*params.UserLoginResponse, *response.CustomError) {
+	var session = new(models.Session)
+	session.UserID = user.Id
+	session.DeviceLabel = device.DeviceLabel
+	session.UserAgent = device.UserAgent
+	session.IPAddress = device.IPAddress
+	session.CreatedAt = time.Now()
+	session.LastUsedAt = session.CreatedAt
+
+	err := service.SessionRepository.CreateSession(ctx, tx, session)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	return service.issueTokens(ctx, tx, user, session.Id, 0)
+}
+
+// issueTokens creates an access token and a refresh token for a session.
+// parentID is the refresh token being replaced, if any.
+func (service *UserServiceImpl) issueTokens(ctx context.Context, tx *sql.Tx, user *models.User, sessionID, parentID uint64) (*params.UserLoginResponse, *response.CustomError) {
+	now := time.Now()
+	accessToken, err := service.Tokens.GenerateToken(int(user.Id), user.Role, sessionID, service.AccessTokenTTL)
 	if err != nil {
 		return nil, response.GeneralErrorWithAdditionalInfo("Failed Generate Token Errors: %s", err.Error())
 	}
 
-	response := params.UserLoginResponse{
-		Token: token,
+	refreshToken, refreshHash, err := token.NewRefreshToken()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Generate Token Errors: %s", err.Error())
 	}
 
-	return &response, nil
+	var stored = new(models.RefreshToken)
+	stored.SessionID = sessionID
+	stored.TokenHash = refreshHash
+	stored.CreatedAt = now
+	stored.ExpiresAt = now.Add(service.RefreshTokenTTL)
+	if parentID > 0 {
+		stored.ParentID = sql.NullInt64{Int64: int64(parentID), Valid: true}
+	}
+
+	err = service.SessionRepository.CreateRefreshToken(ctx, tx, stored)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	return &params.UserLoginResponse{
+		Token:          accessToken,
+		TokenExpiresAt: now.Add(service.AccessTokenTTL),
+		RefreshToken:   refreshToken,
+	}, nil
 }
-func (service *UserServiceImpl) GetAllUser(ctx context.Context) ([]*params.GetAllUser, *response.CustomError) {
+
+// sessionTouchInterval is how stale a session's last_used_at may get before a
+// request through it writes a new one, so that not every request writes.
+const sessionTouchInterval = time.Minute
+
+// ValidateToken checks a token's signature and expiry, then that its session
+// has not been revoked and the account behind it is still allowed in. A
+// suspension or ban takes effect on the next request, not only at the next
+// login.
+func (service *UserServiceImpl) ValidateToken(ctx context.Context, tokenStr string) (*params.AuthenticatedUser, *response.CustomError) {
+	payload, err := service.Tokens.ValidateToken(tokenStr)
+	if err != nil {
+		return nil, response.UnauthorizedError("Invalid token")
+	}
 
 	tx, err := service.MySqlDB.Begin()
 	if err != nil {
@@ -129,21 +490,341 @@ func (service *UserServiceImpl) GetAllUser(ctx context.Context) ([]*params.GetAl
 	}
 	defer helpers.CommitOrRollback(tx)
 
-	users, err := service.UserRepository.FindAllUser(ctx, tx)
+	session, err := service.SessionRepository.FindSessionByID(ctx, tx, payload.SessionID)
+	if err != nil || session.UserID != uint64(payload.AuthId) {
+		return nil, response.UnauthorizedError("Invalid token")
+	}
+	if session.RevokedAt.Valid {
+		return nil, response.UnauthorizedError("Session has been revoked")
+	}
+	if now := time.Now(); now.Sub(session.LastUsedAt) > sessionTouchInterval {
+		session.LastUsedAt = now
+		err = service.SessionRepository.TouchSession(ctx, tx, session)
+		if err != nil {
+			return nil, response.GeneralError(err.Error())
+		}
+	}
+
+	user, err := service.UserRepository.FindUserById(ctx, tx, payload.AuthId)
 	if err != nil {
-		return nil, response.GeneralErrorWithAdditionalInfo("Failed get user errors: %s", err.Error())
+		return nil, response.UnauthorizedError("Invalid token")
+	}
+	if custErr := accountStatusError(user); custErr != nil {
+		return nil, custErr
+	}
+
+	return &params.AuthenticatedUser{
+		UserID:       user.Id,
+		SessionID:    session.Id,
+		Role:         user.Role,
+		ShadowBanned: user.IsShadowBanned(),
+	}, nil
+}
+
+// accountStatusError is the error a banned or suspended user gets instead of
+// a session. Shadow-banned users get none.
+func accountStatusError(user *models.User) *response.CustomError {
+	if user.IsBanned() {
+		return response.AccountBannedError("Account is banned.")
+	}
+	if user.IsSuspended(time.Now()) {
+		var until interface{}
+		if user.SuspendedUntil.Valid {
+			until = map[string]time.Time{"suspended_until": user.SuspendedUntil.Time}
+		}
+		return response.AccountSuspendedErrorWithAdditionalInfo(until, "Account is suspended.")
+	}
+	return nil
+}
+
+const defaultUserPageSize = 50
+
+func (service *UserServiceImpl) SearchUsers(ctx context.Context, req *params.UserSearchRequest) ([]*params.GetAllUser, *response.CustomError) {
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
+	page, limit := req.Page, req.Limit
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = defaultUserPageSize
+	}
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
 	}
+	defer helpers.CommitOrRollback(tx)
 
-	var result []*params.GetAllUser
+	users, err := service.UserRepository.SearchUsers(ctx, tx, req.Query, req.Role, limit, (page-1)*limit)
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed get user errors: %s", err.Error())
+	}
 
+	result := []*params.GetAllUser{}
 	for _, user := range users {
-		result = append(result, &params.GetAllUser{
-			ID:        user.Id,
-			Username:  user.Username,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-		})
+		result = append(result, toUserResponse(user))
+	}
+
+	return result, nil
+}
+
+// SuspendUser locks an account out until req.Until.
+func (service *UserServiceImpl) SuspendUser(ctx context.Context, req *params.ModerationRequest) (*params.GetAllUser, *response.CustomError) {
+	if req.Until == nil || !req.Until.After(time.Now()) {
+		return nil, response.BadRequestErrorWithAdditionalInfo("Suspension must end in the future.")
+	}
+
+	result, sessionIDs, custErr := service.moderate(ctx, req, func(tx *sql.Tx, user *models.User, now time.Time) error {
+		user.SuspendedAt = sql.NullTime{Time: now, Valid: true}
+		user.SuspendedUntil = sql.NullTime{Time: *req.Until, Valid: true}
+		user.SuspensionReason = sql.NullString{String: req.Reason, Valid: true}
+		return service.UserRepository.SuspendUser(ctx, tx, user.Id, req.Reason, now, *req.Until)
+	})
+	if custErr != nil {
+		return nil, custErr
+	}
+
+	service.closeSessions(result.ID, sessionIDs)
+	return result, nil
+}
+
+// BanUser locks an account out for good, until the ban is lifted.
+func (service *UserServiceImpl) BanUser(ctx context.Context, req *params.ModerationRequest) (*params.GetAllUser, *response.CustomError) {
+	result, sessionIDs, custErr := service.moderate(ctx, req, func(tx *sql.Tx, user *models.User, now time.Time) error {
+		user.BannedAt = sql.NullTime{Time: now, Valid: true}
+		user.BanReason = sql.NullString{String: req.Reason, Valid: true}
+		return service.UserRepository.BanUser(ctx, tx, user.Id, req.Reason, now)
+	})
+	if custErr != nil {
+		return nil, custErr
+	}
+
+	service.closeSessions(result.ID, sessionIDs)
+	return result, nil
+}
+
+// ShadowBanUser leaves the account working as far as its owner can tell,
+// while its swipes and messages stop reaching anyone else.
+func (service *UserServiceImpl) ShadowBanUser(ctx context.Context, req *params.ModerationRequest) (*params.GetAllUser, *response.CustomError) {
+	result, _, custErr := service.moderate(ctx, req, func(tx *sql.Tx, user *models.User, now time.Time) error {
+		user.ShadowBannedAt = sql.NullTime{Time: now, Valid: true}
+		return service.UserRepository.ShadowBanUser(ctx, tx, user.Id, now)
+	})
+	if custErr != nil {
+		return nil, custErr
+	}
+
+	service.ShadowBans.UpdateShadowBan(result.ID, true)
+	if err := service.SearchIndex.ShadowBan(ctx, result.ID, true); err != nil {
+		log.Printf("error shadow-banning user %d in search index: %v", result.ID, err)
+	}
+	return result, nil
+}
+
+// moderate loads the target of a moderation action and applies it. It also
+// returns the target's live sessions, for actions whose effect on open
+// connections has to wait until the action is committed.
+func (service *UserServiceImpl) moderate(ctx context.Context, req *params.ModerationRequest, apply func(tx *sql.Tx, user *models.User, now time.Time) error) (*params.GetAllUser, []uint64, *response.CustomError) {
+	if custErr := middleware.AuthorizeUser(ctx, req.ActorID); custErr != nil {
+		return nil, nil, custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, nil, response.BadRequestError()
+	}
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	user, err := service.UserRepository.FindUserById(ctx, tx, int(req.UserID))
+	if err != nil {
+		return nil, nil, response.NotFoundError("User not found.")
+	}
+	if custErr := canModerate(req.ActorRole, user); custErr != nil {
+		return nil, nil, custErr
+	}
+
+	sessions, err := service.SessionRepository.FindActiveSessionsByUserID(ctx, tx, user.Id, time.Time{})
+	if err != nil {
+		return nil, nil, response.GeneralError(err.Error())
 	}
+	sessionIDs := make([]uint64, 0, len(sessions))
+	for _, session := range sessions {
+		sessionIDs = append(sessionIDs, session.Id)
+	}
+
+	err = apply(tx, user, time.Now())
+	if err != nil {
+		return nil, nil, response.GeneralError(err.Error())
+	}
+	return toUserResponse(user), sessionIDs, nil
+}
+
+// canModerate lets moderators act on regular users only and admins on anyone
+// but themselves, which the requests already rule out.
+func canModerate(actorRole string, user *models.User) *response.CustomError {
+	if user.Role != models.RoleUser && actorRole != models.RoleAdmin {
+		return response.ForbiddenError("Only admins can moderate staff accounts.")
+	}
+	return nil
+}
+
+// closeSessions drops the connections of a user who can no longer use the
+// app, so a ban or suspension also ends the conversations already open.
+func (service *UserServiceImpl) closeSessions(userID uint64, sessionIDs []uint64) {
+	if len(sessionIDs) > 0 {
+		service.SessionCloser.CloseSessions(userID, sessionIDs)
+	}
+}
 
+func (service *UserServiceImpl) UnsuspendUser(ctx context.Context, req *params.LiftModerationRequest) (*params.GetAllUser, *response.CustomError) {
+	return service.lift(ctx, req, "User is not suspended.", func(tx *sql.Tx, user *models.User) (bool, error) {
+		if !user.SuspendedAt.Valid {
+			return false, nil
+		}
+		user.SuspendedAt = sql.NullTime{}
+		user.SuspendedUntil = sql.NullTime{}
+		user.SuspensionReason = sql.NullString{}
+		return true, service.UserRepository.UnsuspendUser(ctx, tx, user.Id)
+	})
+}
+
+func (service *UserServiceImpl) UnbanUser(ctx context.Context, req *params.LiftModerationRequest) (*params.GetAllUser, *response.CustomError) {
+	return service.lift(ctx, req, "User is not banned.", func(tx *sql.Tx, user *models.User) (bool, error) {
+		if !user.IsBanned() {
+			return false, nil
+		}
+		user.BannedAt = sql.NullTime{}
+		user.BanReason = sql.NullString{}
+		return true, service.UserRepository.UnbanUser(ctx, tx, user.Id)
+	})
+}
+
+func (service *UserServiceImpl) UnshadowBanUser(ctx context.Context, req *params.LiftModerationRequest) (*params.GetAllUser, *response.CustomError) {
+	result, custErr := service.lift(ctx, req, "User is not shadow-banned.", func(tx *sql.Tx, user *models.User) (bool, error) {
+		if !user.IsShadowBanned() {
+			return false, nil
+		}
+		user.ShadowBannedAt = sql.NullTime{}
+		return true, service.UserRepository.UnshadowBanUser(ctx, tx, user.Id)
+	})
+	if custErr != nil {
+		return nil, custErr
+	}
+
+	service.ShadowBans.UpdateShadowBan(result.ID, false)
+	if err := service.SearchIndex.ShadowBan(ctx, result.ID, false); err != nil {
+		log.Printf("error lifting shadow-ban of user %d in search index: %v", result.ID, err)
+	}
 	return result, nil
 }
+
+// lift removes a restriction from a user, under the same rules as moderate.
+// remove reports false, without writing anything, when the user is not under
+// that restriction.
+func (service *UserServiceImpl) lift(ctx context.Context, req *params.LiftModerationRequest, notRestricted string, remove func(tx *sql.Tx, user *models.User) (bool, error)) (*params.GetAllUser, *response.CustomError) {
+	if custErr := middleware.AuthorizeUser(ctx, req.ActorID); custErr != nil {
+		return nil, custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	user, err := service.UserRepository.FindUserById(ctx, tx, int(req.UserID))
+	if err != nil {
+		return nil, response.NotFoundError("User not found.")
+	}
+	if custErr := canModerate(req.ActorRole, user); custErr != nil {
+		return nil, custErr
+	}
+
+	removed, err := remove(tx, user)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+	if !removed {
+		return nil, response.BadRequestErrorWithAdditionalInfo(notRestricted)
+	}
+	return toUserResponse(user), nil
+}
+
+// UpdateUserRole changes a user's role. ValidateToken reads the role from
+// the account, so it applies to tokens already issued as well.
+func (service *UserServiceImpl) UpdateUserRole(ctx context.Context, req *params.UpdateRoleRequest) (*params.GetAllUser, *response.CustomError) {
+	if custErr := middleware.AuthorizeUser(ctx, req.ActorID); custErr != nil {
+		return nil, custErr
+	}
+
+	val := validator.New()
+	err := val.Struct(req)
+	if err != nil {
+		return nil, response.BadRequestError()
+	}
+
+	tx, err := service.MySqlDB.Begin()
+	if err != nil {
+		return nil, response.GeneralErrorWithAdditionalInfo("Failed Connection to MySQL Errors: %s", err.Error())
+	}
+	defer helpers.CommitOrRollback(tx)
+
+	user, err := service.UserRepository.FindUserById(ctx, tx, int(req.UserID))
+	if err != nil {
+		return nil, response.NotFoundError("User not found.")
+	}
+
+	err = service.UserRepository.UpdateRole(ctx, tx, user.Id, req.Role)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	user.Role = req.Role
+	return toUserResponse(user), nil
+}
+
+func toUserResponse(user *models.User) *params.GetAllUser {
+	var result = &params.GetAllUser{
+		ID:        user.Id,
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+	if user.EmailVerifiedAt.Valid {
+		result.EmailVerifiedAt = &user.EmailVerifiedAt.Time
+	}
+	if user.SuspendedAt.Valid {
+		result.SuspendedAt = &user.SuspendedAt.Time
+		result.SuspensionReason = user.SuspensionReason.String
+	}
+	if user.SuspendedUntil.Valid {
+		result.SuspendedUntil = &user.SuspendedUntil.Time
+	}
+	if user.BannedAt.Valid {
+		result.BannedAt = &user.BannedAt.Time
+		result.BanReason = user.BanReason.String
+	}
+	if user.ShadowBannedAt.Valid {
+		result.ShadowBannedAt = &user.ShadowBannedAt.Time
+	}
+	return result
+}
@@ -0,0 +1,115 @@
+package websockets
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Broker carries deliveries between hub instances so that an event published
+// on one server reaches sockets held by every server.
+type Broker interface {
+	Publish(ctx context.Context, delivery *Delivery) error
+	Subscribe(ctx context.Context) (<-chan *Delivery, error)
+	Close() error
+}
+
+const subscriberBuffer = 256
+
+// MemoryBroker fans deliveries out to every subscriber in the same process.
+// It is the default for a single instance, and several hubs can share one to
+// stand in for a cluster in tests.
+type MemoryBroker struct {
+	mu          sync.RWMutex
+	subscribers []chan *Delivery
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, delivery *Delivery) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, subscriber := range b.subscribers {
+		select {
+		case subscriber <- delivery:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context) (<-chan *Delivery, error) {
+	subscriber := make(chan *Delivery, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers = append(b.subscribers, subscriber)
+	b.mu.Unlock()
+
+	return subscriber, nil
+}
+
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, subscriber := range b.subscribers {
+		close(subscriber)
+	}
+	b.subscribers = nil
+	return nil
+}
+
+// RedisBroker publishes deliveries on a Redis pub/sub channel shared by all
+// instances.
+type RedisBroker struct {
+	client  *redis.Client
+	channel string
+}
+
+func NewRedisBroker(client *redis.Client, channel string) *RedisBroker {
+	return &RedisBroker{
+		client:  client,
+		channel: channel,
+	}
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, delivery *Delivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, data).Err()
+}
+
+func (b *RedisBroker) Subscribe(ctx context.Context) (<-chan *Delivery, error) {
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	deliveries := make(chan *Delivery, subscriberBuffer)
+	go func() {
+		defer close(deliveries)
+		for message := range pubsub.Channel() {
+			var delivery Delivery
+			if err := json.Unmarshal([]byte(message.Payload), &delivery); err != nil {
+				log.Println("Error decoding broker delivery:", err)
+				continue
+			}
+			deliveries <- &delivery
+		}
+	}()
+	return deliveries, nil
+}
+
+func (b *RedisBroker) Close() error {
+	return b.client.Close()
+}
@@ -1,9 +1,13 @@
 package websockets
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"log"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/middleware"
+	"sweatsparks/internal/params"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -15,24 +19,39 @@ const (
 	pingPeriod = (pongWait * 9) / 10
 )
 
-type Client struct {
-	Hub    *Hub
-	Conn   *websocket.Conn
-	Send   chan *Message
-	RoomID string
-	Sender string
-}
+// CloseResyncRequired is the close code sent to a client the hub dropped for
+// not keeping up. The client should reconnect and send a sync event.
+const CloseResyncRequired = 4000
 
-type Message struct {
-	RoomID  string `json:"room_id"`
-	Sender  string `json:"sender"`
-	Content string `json:"content"`
-	Time    string `json:"time"`
-}
+// CloseSessionRevoked is the close code sent when the session the connection
+// was opened with is revoked. The client should not reconnect with the same
+// token.
+const CloseSessionRevoked = 4001
 
-type IncomingMessage struct {
-	Content string `json:"content"`
-	File    string `json:"file"`
+type Client struct {
+	Hub       *Hub
+	Conn      *websocket.Conn
+	Send      chan *Event
+	UserID    uint64
+	SessionID uint64
+	handler   *Handler
+
+	// syncs hands sync requests from the read pump to the write pump, which
+	// replays missed messages before it writes anything else from Send.
+	syncs chan *SyncPayload
+	// replayed holds the newest replayed message ID per match so the live
+	// copies of those messages still queued in Send are not written twice.
+	// It is only touched by the write pump.
+	replayed map[uint64]uint64
+	// dropped is set by the hub before it closes Send on a slow consumer.
+	dropped bool
+	// revoked is set by the hub before it closes Send because the session
+	// was revoked.
+	revoked bool
+	// shadowed is set for shadow-banned users. Their typing indicators go
+	// nowhere; their messages are kept from the peer by the message service.
+	// The hub changes it when a shadow-ban is applied or lifted.
+	shadowed atomic.Bool
 }
 
 func (c *Client) WriteMessage() {
@@ -44,13 +63,34 @@ func (c *Client) WriteMessage() {
 
 	for {
 		select {
-		case message, ok := <-c.Send:
+		case event, ok := <-c.Send:
 			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
-				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				closeMessage := []byte{}
+				if c.revoked {
+					closeMessage = websocket.FormatCloseMessage(CloseSessionRevoked, "session revoked")
+				} else if c.dropped {
+					closeMessage = websocket.FormatCloseMessage(CloseResyncRequired, "resync required")
+				}
+				c.Conn.WriteMessage(websocket.CloseMessage, closeMessage)
+				return
 			}
 
-			c.Conn.WriteJSON(message)
+			if c.alreadyReplayed(event) {
+				continue
+			}
+
+			if err := c.Conn.WriteJSON(event); err != nil {
+				return
+			}
+
+			if event.Type == EventMessageNew {
+				go c.markDelivered(event)
+			}
+		case req := <-c.syncs:
+			if err := c.replay(req); err != nil {
+				return
+			}
 		case <-ticker.C:
 			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
@@ -60,7 +100,7 @@ func (c *Client) WriteMessage() {
 	}
 }
 
-func (c *Client) ReadMessage(db *sql.DB) {
+func (c *Client) ReadMessage() {
 	defer func() {
 		c.Hub.Unregister <- c
 		c.Conn.Close()
@@ -77,29 +117,277 @@ func (c *Client) ReadMessage(db *sql.DB) {
 			break
 		}
 
-		var incomingMsg IncomingMessage
-		err = json.Unmarshal(message, &incomingMsg)
+		var event Event
+		err = json.Unmarshal(message, &event)
 		if err != nil {
-			log.Println("Error decoding JSON message:", err)
+			c.sendError(0, "", response.BadRequestError("Invalid event"))
 			continue
 		}
-		msg := &Message{
-			RoomID:  c.RoomID,
-			Sender:  c.Sender,
-			Content: incomingMsg.Content,
-			Time:    time.Now().Format(time.RFC3339),
+
+		if event.Version != ProtocolVersion {
+			c.sendError(event.MatchID, "", response.BadRequestError("Unsupported protocol version"))
+			continue
 		}
 
-		if err := storeMessage(db, msg); err != nil {
-			log.Printf("errror storing message %v", err)
+		switch event.Type {
+		case EventMessageSend:
+			c.handleMessageSend(&event)
+		case EventMessageRead:
+			c.handleMessageRead(&event)
+		case EventMessageEdit:
+			c.handleMessageEdit(&event)
+		case EventMessageUnsend, EventMessageDelete:
+			c.handleMessageDelete(&event)
+		case EventReactionAdd, EventReactionRemove:
+			c.handleReaction(&event)
+		case EventTypingStart, EventTypingStop:
+			c.handleTyping(&event)
+		case EventSync:
+			c.handleSync(&event)
+		case EventPing:
+			c.reply(EventPong, 0, struct{}{})
+		default:
+			c.sendError(event.MatchID, "", response.BadRequestError("Unknown event type"))
 		}
+	}
+}
+
+// handleMessageSend stores a chat message, acks it back to this connection
+// and fans it out to both sides of the match. Retries carrying an already
+// stored client_message_id are acked again without a second fan-out.
+func (c *Client) handleMessageSend(event *Event) {
+	var payload MessageSendPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		c.sendError(event.MatchID, "", response.BadRequestError("Invalid message payload"))
+		return
+	}
+
+	result, custErr := c.storeMessage(event.MatchID, &payload)
+	if custErr != nil {
+		c.sendError(event.MatchID, payload.ClientMessageID, custErr)
+		return
+	}
+
+	c.reply(EventMessageAck, result.Message.MatchID, &MessageAckPayload{
+		ID:              result.Message.Id,
+		ClientMessageID: result.Message.ClientMessageID,
+		SentAt:          result.Message.SendAt,
+	})
+
+	if result.Duplicate {
+		return
+	}
+
+	outgoing, err := NewEvent(EventMessageNew, result.Message.MatchID, result.Message)
+	if err != nil {
+		log.Println("Error encoding JSON message:", err)
+		return
+	}
+
+	c.Hub.Broadcast <- &Delivery{
+		UserIDs: result.Recipients,
+		Event:   outgoing,
+	}
+}
+
+func (c *Client) storeMessage(matchID uint64, payload *MessageSendPayload) (*params.MessageSendResponse, *response.CustomError) {
+	return c.handler.messageService.SendMessage(c.context(), &params.MessageRequest{
+		MatchID:         matchID,
+		SenderID:        c.UserID,
+		ClientMessageID: payload.ClientMessageID,
+		Content:         payload.Content,
+		AttachmentID:    payload.AttachmentID,
+	})
+}
+
+func (c *Client) handleMessageRead(event *Event) {
+	var payload MessageReadPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		c.sendError(event.MatchID, "", response.BadRequestError("Invalid read payload"))
+		return
+	}
+
+	result, custErr := c.handler.messageService.MarkMessagesRead(c.context(), &params.MessageReceiptRequest{
+		MatchID:   event.MatchID,
+		UserID:    c.UserID,
+		MessageID: payload.MessageID,
+	})
+	if custErr != nil {
+		c.sendError(event.MatchID, "", custErr)
+		return
+	}
+
+	c.broadcastReceipt(EventMessageRead, result)
+}
+
+// handleMessageEdit and handleMessageDelete only report failures back. The
+// message service notifies the affected users itself on success.
+func (c *Client) handleMessageEdit(event *Event) {
+	var payload MessageEditPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		c.sendError(event.MatchID, "", response.BadRequestError("Invalid edit payload"))
+		return
+	}
+
+	_, custErr := c.handler.messageService.EditMessage(c.context(), &params.MessageEditRequest{
+		MatchID:   event.MatchID,
+		MessageID: payload.MessageID,
+		UserID:    c.UserID,
+		Content:   payload.Content,
+	})
+	if custErr != nil {
+		c.sendError(event.MatchID, "", custErr)
+	}
+}
+
+func (c *Client) handleMessageDelete(event *Event) {
+	var payload MessageDeletePayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		c.sendError(event.MatchID, "", response.BadRequestError("Invalid delete payload"))
+		return
+	}
+
+	req := &params.MessageDeleteRequest{
+		MatchID:   event.MatchID,
+		MessageID: payload.MessageID,
+		UserID:    c.UserID,
+	}
+
+	var custErr *response.CustomError
+	if event.Type == EventMessageUnsend {
+		_, custErr = c.handler.messageService.UnsendMessage(c.context(), req)
+	} else {
+		custErr = c.handler.messageService.DeleteMessageForMe(c.context(), req)
+	}
+	if custErr != nil {
+		c.sendError(event.MatchID, "", custErr)
+	}
+}
+
+// handleReaction reports failures back; the reaction service fans out
+// reaction.added and reaction.removed itself.
+func (c *Client) handleReaction(event *Event) {
+	var payload ReactionPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		c.sendError(event.MatchID, "", response.BadRequestError("Invalid reaction payload"))
+		return
+	}
+
+	req := &params.ReactionRequest{
+		MatchID:   event.MatchID,
+		MessageID: payload.MessageID,
+		UserID:    c.UserID,
+		Emoji:     payload.Emoji,
+	}
+
+	var custErr *response.CustomError
+	if event.Type == EventReactionAdd {
+		_, custErr = c.handler.reactionService.AddReaction(c.context(), req)
+	} else {
+		custErr = c.handler.reactionService.RemoveReaction(c.context(), req)
+	}
+	if custErr != nil {
+		c.sendError(event.MatchID, "", custErr)
+	}
+}
+
+func (c *Client) handleTyping(event *Event) {
+	if c.shadowed.Load() {
+		return
+	}
+
+	match, custErr := c.handler.matchService.FindMatchDetailByID(c.context(), int(event.MatchID), int(c.UserID))
+	if custErr != nil {
+		c.sendError(event.MatchID, "", custErr)
+		return
+	}
+
+	peerID := match.UserOne
+	if peerID == c.UserID {
+		peerID = match.UserTwo
+	}
+
+	outgoing, err := NewEvent(event.Type, match.Id, &TypingPayload{UserID: c.UserID})
+	if err != nil {
+		log.Println("Error encoding JSON message:", err)
+		return
+	}
+
+	c.Hub.Broadcast <- &Delivery{
+		UserIDs: []uint64{peerID},
+		Event:   outgoing,
+	}
+}
+
+// markDelivered records that a message written to this connection reached
+// one of the recipient's devices.
+func (c *Client) markDelivered(event *Event) {
+	var message params.MessageResponse
+	if err := json.Unmarshal(event.Payload, &message); err != nil || message.SenderID == c.UserID {
+		return
+	}
+
+	c.markDeliveredUpTo(message.MatchID, message.Id)
+}
+
+// markDeliveredUpTo marks everything the other participant sent up to
+// messageID as delivered and lets them know.
+func (c *Client) markDeliveredUpTo(matchID, messageID uint64) {
+	result, custErr := c.handler.messageService.MarkMessagesDelivered(c.context(), &params.MessageReceiptRequest{
+		MatchID:   matchID,
+		UserID:    c.UserID,
+		MessageID: messageID,
+	})
+	if custErr != nil {
+		log.Printf("error marking message %d delivered: %s", messageID, custErr.Message)
+		return
+	}
+
+	c.broadcastReceipt(EventMessageDelivered, result)
+}
+
+func (c *Client) broadcastReceipt(eventType string, result *params.MessageReceiptResponse) {
+	if result.Updated == 0 {
+		return
+	}
+
+	outgoing, err := NewEvent(eventType, result.MatchID, result)
+	if err != nil {
+		log.Println("Error encoding JSON message:", err)
+		return
+	}
+
+	c.Hub.Broadcast <- &Delivery{
+		UserIDs: result.Recipients,
+		Event:   outgoing,
+	}
+}
+
+// context is what the connection's calls into the services run under. It
+// acts for the connection's user, as a request context does after
+// AuthMiddleware.
+func (c *Client) context() context.Context {
+	return middleware.ContextWithUserID(context.Background(), int64(c.UserID))
+}
+
+// reply sends an event to this connection only.
+func (c *Client) reply(eventType string, matchID uint64, payload interface{}) {
+	event, err := NewEvent(eventType, matchID, payload)
+	if err != nil {
+		log.Println("Error encoding JSON message:", err)
+		return
+	}
 
-		c.Hub.Broadcast <- msg
+	c.Hub.Broadcast <- &Delivery{
+		Client: c,
+		Event:  event,
 	}
 }
 
-func storeMessage(db *sql.DB, msg *Message) error {
-	query := `INSERT INTO messages (match_id,sender_id,content,sent_at) VALUES (?,?,?,?)`
-	_, err := db.Exec(query, msg.RoomID, msg.Sender, msg.Content, msg.Time)
-	return err
+func (c *Client) sendError(matchID uint64, clientMessageID string, err *response.CustomError) {
+	c.reply(EventMessageError, matchID, &MessageErrorPayload{
+		ClientMessageID: clientMessageID,
+		Code:            err.Code,
+		Message:         err.Message,
+	})
 }
@@ -0,0 +1,140 @@
+package websockets
+
+import (
+	"encoding/json"
+	"sweatsparks/internal/services"
+	"time"
+)
+
+// ProtocolVersion is bumped whenever an event payload changes incompatibly.
+// Clients send it as "v" on every event and the server echoes it back.
+const ProtocolVersion = 1
+
+const (
+	EventMessageSend  = "message.send"
+	EventMessageNew   = services.EventMessageNew
+	EventMessageAck   = "message.ack"
+	EventMessageError = "message.error"
+
+	// EventMessageRead is sent by a client to mark everything up to a message
+	// as read, and fanned out to both participants as the receipt.
+	EventMessageRead      = "message.read"
+	EventMessageDelivered = "message.delivered"
+	EventPing             = "ping"
+	EventPong             = "pong"
+
+	// EventSync is sent by a reconnecting client with the last message it saw
+	// per match. Missed messages are replayed as message.new events followed
+	// by one sync.done per match, before any live event is written.
+	EventSync     = "sync"
+	EventSyncDone = "sync.done"
+
+	// Edits and deletes are requested by the sender and confirmed to the
+	// affected users with the past tense event, whether they came in over
+	// the socket or over REST. message.deleted only reaches the caller.
+	EventMessageEdit    = "message.edit"
+	EventMessageUnsend  = "message.unsend"
+	EventMessageDelete  = "message.delete"
+	EventMessageEdited  = services.EventMessageEdited
+	EventMessageUnsent  = services.EventMessageUnsent
+	EventMessageDeleted = services.EventMessageDeleted
+
+	// EventMessageExpired lists disappearing messages the server deleted.
+	EventMessageExpired = services.EventMessageExpired
+
+	// Reactions are set and cleared by a client and fanned out to both
+	// participants.
+	EventReactionAdd     = "reaction.add"
+	EventReactionRemove  = "reaction.remove"
+	EventReactionAdded   = services.EventReactionAdded
+	EventReactionRemoved = services.EventReactionRemoved
+
+	// EventMatchClosed tells both sides a match is gone, e.g. after a block.
+	EventMatchClosed = services.EventMatchClosed
+
+	// Typing events are relayed to the other participant and never stored.
+	EventTypingStart = "typing.start"
+	EventTypingStop  = "typing.stop"
+)
+
+// Event is the envelope for everything sent over a connection. MatchID tells
+// the client which conversation the payload belongs to.
+type Event struct {
+	Version int             `json:"v"`
+	Type    string          `json:"type"`
+	MatchID uint64          `json:"match_id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type MessageSendPayload struct {
+	ClientMessageID string `json:"client_message_id"`
+	Content         string `json:"content"`
+	AttachmentID    uint64 `json:"attachment_id,omitempty"`
+}
+
+type MessageAckPayload struct {
+	ID              uint64    `json:"id"`
+	ClientMessageID string    `json:"client_message_id"`
+	SentAt          time.Time `json:"sent_at"`
+}
+
+type MessageReadPayload struct {
+	MessageID uint64 `json:"message_id"`
+}
+
+type MessageEditPayload struct {
+	MessageID uint64 `json:"message_id"`
+	Content   string `json:"content"`
+}
+
+// MessageDeletePayload is used by both message.unsend and message.delete.
+type MessageDeletePayload struct {
+	MessageID uint64 `json:"message_id"`
+}
+
+// ReactionPayload is used by both reaction.add and reaction.remove, which
+// leaves Emoji empty.
+type ReactionPayload struct {
+	MessageID uint64 `json:"message_id"`
+	Emoji     string `json:"emoji,omitempty"`
+}
+
+type TypingPayload struct {
+	UserID uint64 `json:"user_id"`
+}
+
+type SyncCursor struct {
+	MatchID       uint64 `json:"match_id"`
+	LastMessageID uint64 `json:"last_message_id"`
+}
+
+type SyncPayload struct {
+	Cursors []SyncCursor `json:"cursors"`
+}
+
+// SyncDonePayload closes the replay of one match. HasMore means the replay was
+// cut short and the rest has to be fetched through the history API.
+type SyncDonePayload struct {
+	LastMessageID uint64 `json:"last_message_id"`
+	HasMore       bool   `json:"has_more"`
+}
+
+type MessageErrorPayload struct {
+	ClientMessageID string `json:"client_message_id,omitempty"`
+	Code            string `json:"code"`
+	Message         string `json:"message"`
+}
+
+func NewEvent(eventType string, matchID uint64, payload interface{}) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		Version: ProtocolVersion,
+		Type:    eventType,
+		MatchID: matchID,
+		Payload: data,
+	}, nil
+}
@@ -1,52 +1,229 @@
 package websockets
 
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Resubscribing to the broker backs off from minResubscribeDelay, doubling up
+// to maxResubscribeDelay, while the hub keeps serving local connections.
+const (
+	minResubscribeDelay = 500 * time.Millisecond
+	maxResubscribeDelay = 30 * time.Second
+)
+
+// Delivery is an event addressed to every connection of the listed users,
+// or only to Client when it is set. Client deliveries are replies to a
+// connection on this instance and never leave it. A delivery with
+// RevokedSessions carries no event; it closes the listed users' connections
+// that belong to those sessions. Neither does one with ShadowBanned, which
+// applies a shadow-ban or its lifting to the listed users' connections.
+type Delivery struct {
+	UserIDs         []uint64 `json:"user_ids"`
+	Client          *Client  `json:"-"`
+	Event           *Event   `json:"event,omitempty"`
+	RevokedSessions []uint64 `json:"revoked_sessions,omitempty"`
+	ShadowBanned    *bool    `json:"shadow_banned,omitempty"`
+}
+
+// PresenceTracker is told when a user's first connection registers and when
+// their last one goes away. It is called from the hub loop and must not block.
+type PresenceTracker interface {
+	SetOnline(userID uint64)
+	SetOffline(userID uint64)
+}
+
 type Hub struct {
-	Rooms      map[string]map[*Client]bool
+	Clients    map[uint64]map[*Client]bool
 	Register   chan *Client
 	Unregister chan *Client
-	Broadcast  chan *Message
+	Broadcast  chan *Delivery
+	presence   PresenceTracker
+	broker     Broker
+	local      chan *Delivery
+	subscribed chan (<-chan *Delivery)
 }
 
-func NewHub() *Hub {
+func NewHub(presence PresenceTracker, broker Broker) *Hub {
 	return &Hub{
-		Rooms:      make(map[string]map[*Client]bool),
+		Clients:    make(map[uint64]map[*Client]bool),
 		Register:   make(chan *Client),
 		Unregister: make(chan *Client),
-		Broadcast:  make(chan *Message),
+		Broadcast:  make(chan *Delivery),
+		presence:   presence,
+		broker:     broker,
+		local:      make(chan *Delivery),
+		subscribed: make(chan (<-chan *Delivery)),
+	}
+}
+
+// Notify implements services.Notifier so services can push events for changes
+// made outside a WebSocket connection.
+func (h *Hub) Notify(userIDs []uint64, eventType string, matchID uint64, payload interface{}) {
+	event, err := NewEvent(eventType, matchID, payload)
+	if err != nil {
+		log.Println("Error encoding JSON message:", err)
+		return
+	}
+
+	h.Broadcast <- &Delivery{
+		UserIDs: userIDs,
+		Event:   event,
+	}
+}
+
+// CloseSessions implements services.SessionCloser. The connections are closed
+// on whichever instance holds them.
+func (h *Hub) CloseSessions(userID uint64, sessionIDs []uint64) {
+	h.Broadcast <- &Delivery{
+		UserIDs:         []uint64{userID},
+		RevokedSessions: sessionIDs,
+	}
+}
+
+// UpdateShadowBan implements services.ShadowBanUpdater, so a shadow-ban takes
+// effect on connections opened before it.
+func (h *Hub) UpdateShadowBan(userID uint64, shadowBanned bool) {
+	h.Broadcast <- &Delivery{
+		UserIDs:      []uint64{userID},
+		ShadowBanned: &shadowBanned,
 	}
 }
 
 func (h *Hub) Run() {
+	inbound, err := h.broker.Subscribe(context.Background())
+	if err != nil {
+		log.Printf("error subscribing to hub broker: %v", err)
+		go h.resubscribe()
+	}
+
+	go h.publish()
+
 	for {
 		select {
 		case client := <-h.Register:
-			if _, ok := h.Rooms[client.RoomID]; !ok {
-				h.Rooms[client.RoomID] = make(map[*Client]bool)
+			if _, ok := h.Clients[client.UserID]; !ok {
+				h.Clients[client.UserID] = make(map[*Client]bool)
+				h.presence.SetOnline(client.UserID)
 			}
-			h.Rooms[client.RoomID][client] = true
+			h.Clients[client.UserID][client] = true
 		case client := <-h.Unregister:
-			if clients, ok := h.Rooms[client.RoomID]; ok {
-				if _, ok := clients[client]; ok {
-					delete(clients, client)
-					close(client.Send)
-					if len(clients) == 0 {
-						delete(h.Rooms, client.RoomID)
-					}
-				}
+			h.remove(client)
+		case delivery := <-h.local:
+			h.deliver(delivery)
+		case inbound = <-h.subscribed:
+		case delivery, ok := <-inbound:
+			if !ok {
+				// Deliveries from other instances are missed until the
+				// new subscription is up; local ones keep flowing.
+				log.Println("Hub broker subscription closed, resubscribing")
+				inbound = nil
+				go h.resubscribe()
+				continue
+			}
+			h.deliver(delivery)
+		}
+	}
+}
+
+// resubscribe retries the broker subscription until it succeeds and hands
+// the new channel to Run.
+func (h *Hub) resubscribe() {
+	delay := minResubscribeDelay
+	for {
+		inbound, err := h.broker.Subscribe(context.Background())
+		if err == nil {
+			h.subscribed <- inbound
+			return
+		}
+
+		log.Printf("error subscribing to hub broker, retrying in %s: %v", delay, err)
+		time.Sleep(delay)
+		if delay *= 2; delay > maxResubscribeDelay {
+			delay = maxResubscribeDelay
+		}
+	}
+}
+
+// publish hands deliveries from Broadcast to the broker. It runs apart from
+// Run so a slow broker never stalls local registration and delivery.
+func (h *Hub) publish() {
+	for delivery := range h.Broadcast {
+		if delivery.Client != nil {
+			h.local <- delivery
+			continue
+		}
+
+		if err := h.broker.Publish(context.Background(), delivery); err != nil {
+			log.Printf("error publishing to hub broker, delivering locally only: %v", err)
+			h.local <- delivery
+		}
+	}
+}
+
+func (h *Hub) deliver(delivery *Delivery) {
+	if len(delivery.RevokedSessions) > 0 {
+		h.closeSessions(delivery)
+		return
+	}
+
+	if delivery.ShadowBanned != nil {
+		for _, userID := range delivery.UserIDs {
+			for client := range h.Clients[userID] {
+				client.shadowed.Store(*delivery.ShadowBanned)
+			}
+		}
+		return
+	}
+
+	if delivery.Client != nil {
+		if h.Clients[delivery.Client.UserID][delivery.Client] {
+			h.send(delivery.Client, delivery.Event)
+		}
+		return
+	}
+
+	for _, userID := range delivery.UserIDs {
+		for client := range h.Clients[userID] {
+			h.send(client, delivery.Event)
+		}
+	}
+}
+
+func (h *Hub) closeSessions(delivery *Delivery) {
+	revoked := make(map[uint64]bool, len(delivery.RevokedSessions))
+	for _, sessionID := range delivery.RevokedSessions {
+		revoked[sessionID] = true
+	}
+
+	for _, userID := range delivery.UserIDs {
+		for client := range h.Clients[userID] {
+			if revoked[client.SessionID] {
+				client.revoked = true
+				h.remove(client)
 			}
-		case message := <-h.Broadcast:
-			if clients, ok := h.Rooms[message.RoomID]; ok {
-				for client := range clients {
-					select {
-					case client.Send <- message:
-					default:
-						close(client.Send)
-						delete(clients, client)
-						if len(clients) == 0 {
-							delete(h.Rooms, message.RoomID)
-						}
-					}
-				}
+		}
+	}
+}
+
+func (h *Hub) send(client *Client, event *Event) {
+	select {
+	case client.Send <- event:
+	default:
+		client.dropped = true
+		h.remove(client)
+	}
+}
+
+func (h *Hub) remove(client *Client) {
+	if clients, ok := h.Clients[client.UserID]; ok {
+		if _, ok := clients[client]; ok {
+			delete(clients, client)
+			close(client.Send)
+			if len(clients) == 0 {
+				delete(h.Clients, client.UserID)
+				h.presence.SetOffline(client.UserID)
 			}
 		}
 	}
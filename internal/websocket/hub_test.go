@@ -0,0 +1,143 @@
+package websockets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type noopPresence struct{}
+
+func (noopPresence) SetOnline(userID uint64)  {}
+func (noopPresence) SetOffline(userID uint64) {}
+
+func newTestClient(hub *Hub, userID uint64) *Client {
+	client := &Client{
+		Hub:    hub,
+		Send:   make(chan *Event, 8),
+		UserID: userID,
+	}
+	hub.Register <- client
+	return client
+}
+
+func receive(t *testing.T, client *Client) *Event {
+	t.Helper()
+	select {
+	case event := <-client.Send:
+		return event
+	case <-time.After(time.Second):
+		t.Fatalf("no event delivered to user %d", client.UserID)
+		return nil
+	}
+}
+
+func TestHubDeliversAcrossInstancesSharingABroker(t *testing.T) {
+	broker := NewMemoryBroker()
+	nodeA := NewHub(noopPresence{}, broker)
+	nodeB := NewHub(noopPresence{}, broker)
+	go nodeA.Run()
+	go nodeB.Run()
+
+	sender := newTestClient(nodeA, 1)
+	recipient := newTestClient(nodeB, 2)
+
+	event, err := NewEvent(EventMessageNew, 10, map[string]string{"content": "hi"})
+	require.NoError(t, err)
+	nodeA.Broadcast <- &Delivery{UserIDs: []uint64{1, 2}, Event: event}
+
+	require.Equal(t, EventMessageNew, receive(t, sender).Type)
+	require.Equal(t, uint64(10), receive(t, recipient).MatchID)
+}
+
+func TestHubKeepsClientRepliesLocal(t *testing.T) {
+	broker := NewMemoryBroker()
+	nodeA := NewHub(noopPresence{}, broker)
+	nodeB := NewHub(noopPresence{}, broker)
+	go nodeA.Run()
+	go nodeB.Run()
+
+	client := newTestClient(nodeA, 1)
+	otherDevice := newTestClient(nodeB, 1)
+
+	event, err := NewEvent(EventPong, 0, struct{}{})
+	require.NoError(t, err)
+	nodeA.Broadcast <- &Delivery{Client: client, Event: event}
+
+	require.Equal(t, EventPong, receive(t, client).Type)
+	select {
+	case event := <-otherDevice.Send:
+		t.Fatalf("reply leaked to another connection: %s", event.Type)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestHubClosesRevokedSessionsOnEveryInstance(t *testing.T) {
+	broker := NewMemoryBroker()
+	nodeA := NewHub(noopPresence{}, broker)
+	nodeB := NewHub(noopPresence{}, broker)
+	go nodeA.Run()
+	go nodeB.Run()
+
+	revoked := &Client{Hub: nodeB, Send: make(chan *Event, 8), UserID: 1, SessionID: 7}
+	kept := &Client{Hub: nodeB, Send: make(chan *Event, 8), UserID: 1, SessionID: 8}
+	nodeB.Register <- revoked
+	nodeB.Register <- kept
+
+	nodeA.CloseSessions(1, []uint64{7})
+
+	select {
+	case _, ok := <-revoked.Send:
+		require.False(t, ok, "revoked connection got an event instead of being closed")
+	case <-time.After(time.Second):
+		t.Fatal("revoked connection was not closed")
+	}
+
+	event, err := NewEvent(EventPong, 0, struct{}{})
+	require.NoError(t, err)
+	nodeA.Broadcast <- &Delivery{UserIDs: []uint64{1}, Event: event}
+	require.Equal(t, EventPong, receive(t, kept).Type)
+}
+
+func TestHubAppliesShadowBanToOpenConnections(t *testing.T) {
+	broker := NewMemoryBroker()
+	nodeA := NewHub(noopPresence{}, broker)
+	nodeB := NewHub(noopPresence{}, broker)
+	go nodeA.Run()
+	go nodeB.Run()
+
+	client := &Client{Hub: nodeB, Send: make(chan *Event, 8), UserID: 1}
+	nodeB.Register <- client
+
+	nodeA.UpdateShadowBan(1, true)
+	require.Eventually(t, client.shadowed.Load, time.Second, 10*time.Millisecond)
+
+	nodeA.UpdateShadowBan(1, false)
+	require.Eventually(t, func() bool { return !client.shadowed.Load() }, time.Second, 10*time.Millisecond)
+}
+
+func TestHubResubscribesWhenTheBrokerSubscriptionCloses(t *testing.T) {
+	broker := NewMemoryBroker()
+	nodeA := NewHub(noopPresence{}, broker)
+	nodeB := NewHub(noopPresence{}, broker)
+	go nodeA.Run()
+	go nodeB.Run()
+
+	recipient := newTestClient(nodeB, 2)
+	require.NoError(t, broker.Close())
+
+	// Deliveries published before both hubs resubscribed are lost, so keep
+	// sending until one gets through.
+	event, err := NewEvent(EventPong, 0, struct{}{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		nodeA.Broadcast <- &Delivery{UserIDs: []uint64{2}, Event: event}
+		select {
+		case received := <-recipient.Send:
+			return received.Type == EventPong
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,107 @@
+package websockets
+
+import (
+	"encoding/json"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/params"
+	"time"
+)
+
+const (
+	syncPageSize      = 100
+	maxReplayPerMatch = 1000
+)
+
+func (c *Client) handleSync(event *Event) {
+	var payload SyncPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		c.sendError(0, "", response.BadRequestError("Invalid sync payload"))
+		return
+	}
+
+	select {
+	case c.syncs <- &payload:
+	default:
+		c.sendError(0, "", response.BadRequestError("Sync already in progress"))
+	}
+}
+
+// replay writes every message stored after each cursor, oldest first, starting
+// from the first message of a match when the cursor is 0. It runs on the write
+// pump, so live events keep queueing in Send until it returns.
+func (c *Client) replay(req *SyncPayload) error {
+	for _, cursor := range req.Cursors {
+		if err := c.replayMatch(cursor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) replayMatch(cursor SyncCursor) error {
+	lastID := cursor.LastMessageID
+	replayed := 0
+	hasMore := true
+
+	for hasMore && replayed < maxReplayPerMatch {
+		page, custErr := c.handler.messageService.GetMessageByMatchId(c.context(), &params.MessageHistoryRequest{
+			MatchID: cursor.MatchID,
+			UserID:  c.UserID,
+			After:   lastID,
+			Forward: true,
+			Limit:   syncPageSize,
+		})
+		if custErr != nil {
+			return c.writeEvent(EventMessageError, cursor.MatchID, &MessageErrorPayload{
+				Code:    custErr.Code,
+				Message: custErr.Message,
+			})
+		}
+
+		for i := len(page.Messages) - 1; i >= 0; i-- {
+			if err := c.writeEvent(EventMessageNew, cursor.MatchID, page.Messages[i]); err != nil {
+				return err
+			}
+			lastID = page.Messages[i].Id
+			replayed++
+		}
+		hasMore = page.HasMore
+	}
+
+	if lastID > c.replayed[cursor.MatchID] {
+		c.replayed[cursor.MatchID] = lastID
+	}
+	if lastID > cursor.LastMessageID {
+		go c.markDeliveredUpTo(cursor.MatchID, lastID)
+	}
+
+	return c.writeEvent(EventSyncDone, cursor.MatchID, &SyncDonePayload{
+		LastMessageID: lastID,
+		HasMore:       hasMore,
+	})
+}
+
+// alreadyReplayed reports whether a live message.new was already written to
+// the client as part of a replay.
+func (c *Client) alreadyReplayed(event *Event) bool {
+	lastID, ok := c.replayed[event.MatchID]
+	if !ok || event.Type != EventMessageNew {
+		return false
+	}
+
+	var message params.MessageResponse
+	if err := json.Unmarshal(event.Payload, &message); err != nil {
+		return false
+	}
+	return message.Id <= lastID
+}
+
+func (c *Client) writeEvent(eventType string, matchID uint64, payload interface{}) error {
+	event, err := NewEvent(eventType, matchID, payload)
+	if err != nil {
+		return err
+	}
+
+	c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.Conn.WriteJSON(event)
+}
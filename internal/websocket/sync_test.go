@@ -0,0 +1,94 @@
+package websockets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/params"
+	"sweatsparks/internal/services"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// historyService pages through message IDs 1 to total the way
+// MessageServiceImpl.GetMessageByMatchId does.
+type historyService struct {
+	services.MessageService
+	total uint64
+}
+
+func (service *historyService) GetMessageByMatchId(ctx context.Context, req *params.MessageHistoryRequest) (*params.ConversationResponse, *response.CustomError) {
+	result := &params.ConversationResponse{MatchID: req.MatchID}
+	if req.After > 0 || req.Forward {
+		for id := req.After + 1; id <= service.total; id++ {
+			if len(result.Messages) == req.Limit {
+				result.HasMore = true
+				break
+			}
+			result.Messages = append([]*params.MessageResponse{{Id: id, MatchID: req.MatchID}}, result.Messages...)
+		}
+		return result, nil
+	}
+
+	for id := service.total; id > 0; id-- {
+		if len(result.Messages) == req.Limit {
+			result.HasMore = true
+			break
+		}
+		result.Messages = append(result.Messages, &params.MessageResponse{Id: id, MatchID: req.MatchID})
+	}
+	return result, nil
+}
+
+func (service *historyService) MarkMessagesDelivered(ctx context.Context, req *params.MessageReceiptRequest) (*params.MessageReceiptResponse, *response.CustomError) {
+	return &params.MessageReceiptResponse{MatchID: req.MatchID}, nil
+}
+
+func TestSyncWithoutCursorReplaysFromFirstMessage(t *testing.T) {
+	const total = 2*syncPageSize + 50
+	handler := &Handler{messageService: &historyService{total: total}}
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		client := &Client{Conn: conn, UserID: 1, handler: handler, replayed: make(map[uint64]uint64)}
+		client.replayMatch(SyncCursor{MatchID: 10})
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var next uint64 = 1
+	for {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		var event Event
+		require.NoError(t, conn.ReadJSON(&event))
+
+		if event.Type == EventSyncDone {
+			var done SyncDonePayload
+			require.NoError(t, json.Unmarshal(event.Payload, &done))
+			require.Equal(t, SyncDonePayload{LastMessageID: total, HasMore: false}, done)
+			break
+		}
+
+		require.Equal(t, EventMessageNew, event.Type)
+		var message params.MessageResponse
+		require.NoError(t, json.Unmarshal(event.Payload, &message))
+		require.Equal(t, next, message.Id)
+		next++
+	}
+	require.Equal(t, uint64(total+1), next)
+}
@@ -1,38 +1,61 @@
 package websockets
 
 import (
-	"database/sql"
+	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
+	"sweatsparks/internal/commons/response"
+	"sweatsparks/internal/middleware"
+	"sweatsparks/internal/services"
 
-	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 )
 
+// tokenSubprotocol is the Sec-WebSocket-Protocol value browsers send in front
+// of the JWT, since they cannot set an Authorization header on the handshake.
+const tokenSubprotocol = "access_token"
+
 type Handler struct {
-	hub *Hub
-	db  *sql.DB
+	hub             *Hub
+	tokens          middleware.TokenValidator
+	matchService    services.MatchService
+	messageService  services.MessageService
+	reactionService services.ReactionService
 }
 
-func NewHandler(h *Hub, db *sql.DB) *Handler {
+func NewHandler(h *Hub, tokens middleware.TokenValidator, matchService services.MatchService, messageService services.MessageService, reactionService services.ReactionService) *Handler {
 	return &Handler{
-		hub: h,
-		db:  db,
+		hub:             h,
+		tokens:          tokens,
+		matchService:    matchService,
+		messageService:  messageService,
+		reactionService: reactionService,
 	}
 }
 
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
+	Subprotocols:    []string{tokenSubprotocol},
 	CheckOrigin: func(r *http.Request) bool {
 		return true
 	},
 }
 
 func (h *Handler) ServeWs(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userID := vars["userID"]
-	roomID := vars["roomID"]
+	tokenStr := tokenFromRequest(r)
+	if tokenStr == "" {
+		writeError(w, response.UnauthorizedError("Missing access token"))
+		return
+	}
+
+	user, custErr := h.tokens.ValidateToken(r.Context(), tokenStr)
+	if custErr != nil {
+		writeError(w, custErr)
+		return
+	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println(err)
@@ -40,14 +63,46 @@ func (h *Handler) ServeWs(w http.ResponseWriter, r *http.Request) {
 	}
 
 	client := &Client{
-		Hub:    h.hub,
-		Conn:   conn,
-		Send:   make(chan *Message, 256),
-		RoomID: roomID,
-		Sender: userID,
+		Hub:       h.hub,
+		Conn:      conn,
+		Send:      make(chan *Event, 256),
+		UserID:    user.UserID,
+		SessionID: user.SessionID,
+		handler:   h,
+		syncs:     make(chan *SyncPayload, 1),
+		replayed:  make(map[uint64]uint64),
 	}
+	client.shadowed.Store(user.ShadowBanned)
 	client.Hub.Register <- client
 
 	go client.WriteMessage()
-	go client.ReadMessage(h.db)
+	go client.ReadMessage()
+}
+
+// tokenFromRequest looks for the JWT in the token query param, the
+// access_token subprotocol and the Authorization header, in that order.
+func tokenFromRequest(r *http.Request) string {
+	if tokenStr := r.URL.Query().Get("token"); tokenStr != "" {
+		return tokenStr
+	}
+
+	protocols := websocket.Subprotocols(r)
+	for i, protocol := range protocols {
+		if protocol == tokenSubprotocol && i+1 < len(protocols) {
+			return protocols[i+1]
+		}
+	}
+
+	parts := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(parts) == 2 && parts[0] == "Bearer" {
+		return parts[1]
+	}
+
+	return ""
+}
+
+func writeError(w http.ResponseWriter, err *response.CustomError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.StatusCode)
+	json.NewEncoder(w).Encode(err)
 }
@@ -0,0 +1,3 @@
+ALTER TABLE messages
+    ADD COLUMN client_message_id VARCHAR(64) NULL AFTER sender_id,
+    ADD UNIQUE KEY uq_messages_sender_client_message (sender_id, client_message_id);
@@ -0,0 +1,4 @@
+ALTER TABLE messages
+    ADD COLUMN delivered_at DATETIME NULL AFTER sent_at,
+    ADD COLUMN read_at DATETIME NULL AFTER delivered_at,
+    ADD KEY idx_messages_match_read (match_id, read_at);
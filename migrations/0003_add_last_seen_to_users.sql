@@ -0,0 +1,2 @@
+ALTER TABLE users
+    ADD COLUMN last_seen_at DATETIME NULL AFTER updated_at;
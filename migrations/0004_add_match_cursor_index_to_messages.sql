@@ -0,0 +1,2 @@
+ALTER TABLE messages
+    ADD KEY idx_messages_match_cursor (match_id, id);
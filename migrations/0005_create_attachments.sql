@@ -0,0 +1,16 @@
+CREATE TABLE attachments (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    match_id BIGINT UNSIGNED NOT NULL,
+    uploader_id BIGINT UNSIGNED NOT NULL,
+    storage_key VARCHAR(255) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT UNSIGNED NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (id),
+    KEY idx_attachments_match (match_id)
+);
+
+ALTER TABLE messages
+    ADD COLUMN attachment_id BIGINT UNSIGNED NULL AFTER content,
+    ADD UNIQUE KEY uq_messages_attachment (attachment_id);
@@ -0,0 +1,20 @@
+ALTER TABLE messages
+    ADD COLUMN edited_at DATETIME NULL AFTER read_at,
+    ADD COLUMN deleted_at DATETIME NULL AFTER edited_at;
+
+CREATE TABLE message_edits (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    message_id BIGINT UNSIGNED NOT NULL,
+    content TEXT NOT NULL,
+    edited_at DATETIME NOT NULL,
+    PRIMARY KEY (id),
+    KEY idx_message_edits_message (message_id)
+);
+
+CREATE TABLE message_hidden (
+    message_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    hidden_at DATETIME NOT NULL,
+    PRIMARY KEY (message_id, user_id),
+    KEY idx_message_hidden_user (user_id)
+);
@@ -0,0 +1,7 @@
+CREATE TABLE message_reactions (
+    message_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    emoji VARCHAR(16) NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (message_id, user_id)
+) DEFAULT CHARSET = utf8mb4;
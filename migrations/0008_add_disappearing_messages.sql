@@ -0,0 +1,9 @@
+ALTER TABLE matches
+    ADD COLUMN disappear_after INT UNSIGNED NOT NULL DEFAULT 0,
+    ADD COLUMN disappear_trigger VARCHAR(8) NOT NULL DEFAULT 'read';
+
+ALTER TABLE messages
+    ADD COLUMN type VARCHAR(16) NOT NULL DEFAULT 'text' AFTER sender_id,
+    ADD COLUMN expire_after INT UNSIGNED NULL AFTER deleted_at,
+    ADD COLUMN expires_at DATETIME NULL AFTER expire_after,
+    ADD KEY idx_messages_expires_at (expires_at);
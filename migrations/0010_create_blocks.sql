@@ -0,0 +1,9 @@
+CREATE TABLE blocks (
+    blocker_id BIGINT UNSIGNED NOT NULL,
+    blocked_id BIGINT UNSIGNED NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (blocker_id, blocked_id),
+    KEY idx_blocks_blocked (blocked_id)
+);
+
+ALTER TABLE matches ADD COLUMN closed_at DATETIME NULL;
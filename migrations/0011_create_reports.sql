@@ -0,0 +1,19 @@
+CREATE TABLE reports (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    reporter_id BIGINT UNSIGNED NOT NULL,
+    reported_user_id BIGINT UNSIGNED NOT NULL,
+    target_type VARCHAR(16) NOT NULL,
+    target_id BIGINT UNSIGNED NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    details TEXT NOT NULL,
+    snapshot JSON NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    reviewer_id BIGINT UNSIGNED NULL,
+    review_note TEXT NULL,
+    reviewed_at DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (id),
+    KEY idx_reports_status (status, id),
+    KEY idx_reports_target (target_type, target_id),
+    KEY idx_reports_reporter (reporter_id)
+);
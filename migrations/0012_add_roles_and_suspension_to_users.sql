@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'user' AFTER password_hash,
+    ADD COLUMN suspended_at DATETIME NULL,
+    ADD COLUMN suspension_reason VARCHAR(255) NULL,
+    ADD KEY idx_users_role (role);
@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN suspended_until DATETIME NULL AFTER suspended_at,
+    ADD COLUMN banned_at DATETIME NULL,
+    ADD COLUMN ban_reason VARCHAR(255) NULL,
+    ADD COLUMN shadow_banned_at DATETIME NULL;
@@ -0,0 +1,11 @@
+ALTER TABLE sessions
+    ADD COLUMN device_label VARCHAR(100) NOT NULL DEFAULT '' AFTER user_id,
+    ADD COLUMN user_agent VARCHAR(255) NOT NULL DEFAULT '' AFTER device_label,
+    ADD COLUMN ip_address VARCHAR(45) NOT NULL DEFAULT '' AFTER user_agent,
+    ADD COLUMN last_used_at DATETIME NULL AFTER created_at;
+
+UPDATE sessions SET last_used_at = created_at;
+
+ALTER TABLE sessions
+    MODIFY COLUMN last_used_at DATETIME NOT NULL,
+    ADD KEY idx_sessions_user_active (user_id, revoked_at, last_used_at);
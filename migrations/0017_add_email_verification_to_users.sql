@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN email_verified_at DATETIME NULL AFTER email,
+    ADD COLUMN email_verification_sent_at DATETIME NULL AFTER email_verified_at;
+
+-- Accounts from before verification existed keep working.
+UPDATE users SET email_verified_at = created_at;
@@ -0,0 +1,18 @@
+-- Two reciprocal swipes landing together could each create a match. A pair
+-- may now have one open match; closed matches are kept as history.
+ALTER TABLE matches
+    ADD COLUMN pair_low_id BIGINT UNSIGNED AS (LEAST(user_one_id, user_two_id)) STORED,
+    ADD COLUMN pair_high_id BIGINT UNSIGNED AS (GREATEST(user_one_id, user_two_id)) STORED,
+    ADD COLUMN pair_open TINYINT AS (IF(closed_at IS NULL, 1, NULL)) STORED;
+
+-- Close the duplicates already created, keeping the oldest match of a pair.
+UPDATE matches newer
+    JOIN matches older
+        ON older.pair_low_id = newer.pair_low_id
+        AND older.pair_high_id = newer.pair_high_id
+        AND older.id < newer.id
+SET newer.closed_at = NOW()
+WHERE newer.closed_at IS NULL AND older.closed_at IS NULL;
+
+ALTER TABLE matches
+    ADD UNIQUE KEY uq_matches_open_pair (pair_low_id, pair_high_id, pair_open);
@@ -0,0 +1,20 @@
+-- Two submissions landing together could each pass the open report check. A
+-- reporter may now have one open report per target; reviewed reports are kept
+-- as history.
+ALTER TABLE reports
+    ADD COLUMN report_open TINYINT AS (IF(status = 'open', 1, NULL)) STORED;
+
+-- Dismiss the duplicates already created, keeping the oldest open report.
+UPDATE reports newer
+    JOIN reports older
+        ON older.reporter_id = newer.reporter_id
+        AND older.target_type = newer.target_type
+        AND older.target_id = newer.target_id
+        AND older.id < newer.id
+SET newer.status = 'dismissed',
+    newer.review_note = 'Duplicate of an earlier open report.',
+    newer.reviewed_at = NOW()
+WHERE newer.status = 'open' AND older.status = 'open';
+
+ALTER TABLE reports
+    ADD UNIQUE KEY uq_reports_open_target (reporter_id, target_type, target_id, report_open);
@@ -0,0 +1,15 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// RandomHex returns n random bytes from crypto/rand encoded as hex.
+func RandomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
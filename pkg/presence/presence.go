@@ -0,0 +1,140 @@
+package presence
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TTL is how long an instance's claim on a user lasts unless it is added
+// again. Instances re-add their users well within TTL, so users of an
+// instance that dies without removing them go offline once TTL passes.
+const TTL = 90 * time.Second
+
+// Store records which users hold a WebSocket connection to which server
+// instance, so every instance reports the same presence.
+type Store interface {
+	// Add marks the users as connected to this instance.
+	Add(ctx context.Context, userIDs ...uint64) error
+	// Remove unmarks the user on this instance and reports whether they are
+	// still connected to another instance.
+	Remove(ctx context.Context, userID uint64) (bool, error)
+	// Online reports which of the users are connected to any instance.
+	Online(ctx context.Context, userIDs []uint64) (map[uint64]bool, error)
+}
+
+// MemoryStore keeps presence in the process. It is the default for a single
+// instance.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	online map[uint64]bool
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{online: make(map[uint64]bool)}
+}
+
+func (s *MemoryStore) Add(ctx context.Context, userIDs ...uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, userID := range userIDs {
+		s.online[userID] = true
+	}
+	return nil
+}
+
+func (s *MemoryStore) Remove(ctx context.Context, userID uint64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.online, userID)
+	return false, nil
+}
+
+func (s *MemoryStore) Online(ctx context.Context, userIDs []uint64) (map[uint64]bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	online := make(map[uint64]bool, len(userIDs))
+	for _, userID := range userIDs {
+		online[userID] = s.online[userID]
+	}
+	return online, nil
+}
+
+// RedisStore keeps one sorted set per user in Redis. Its members are the
+// instances holding a connection for the user, scored by when the claim
+// expires.
+type RedisStore struct {
+	client   *redis.Client
+	prefix   string
+	instance string
+}
+
+func NewRedisStore(client *redis.Client, prefix string) (*RedisStore, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return &RedisStore{
+		client:   client,
+		prefix:   prefix,
+		instance: hex.EncodeToString(id),
+	}, nil
+}
+
+func (s *RedisStore) key(userID uint64) string {
+	return s.prefix + ":" + strconv.FormatUint(userID, 10)
+}
+
+func (s *RedisStore) Add(ctx context.Context, userIDs ...uint64) error {
+	expires := float64(time.Now().Add(TTL).UnixMilli())
+
+	pipe := s.client.Pipeline()
+	for _, userID := range userIDs {
+		pipe.ZAdd(ctx, s.key(userID), redis.Z{Score: expires, Member: s.instance})
+		pipe.PExpire(ctx, s.key(userID), TTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisStore) Remove(ctx context.Context, userID uint64) (bool, error) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	pipe := s.client.TxPipeline()
+	pipe.ZRem(ctx, s.key(userID), s.instance)
+	pipe.ZRemRangeByScore(ctx, s.key(userID), "-inf", now)
+	remaining := pipe.ZCard(ctx, s.key(userID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return remaining.Val() > 0, nil
+}
+
+func (s *RedisStore) Online(ctx context.Context, userIDs []uint64) (map[uint64]bool, error) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	pipe := s.client.Pipeline()
+	counts := make(map[uint64]*redis.IntCmd, len(userIDs))
+	for _, userID := range userIDs {
+		counts[userID] = pipe.ZCount(ctx, s.key(userID), "("+now, "+inf")
+	}
+	if len(counts) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	online := make(map[uint64]bool, len(userIDs))
+	for userID, count := range counts {
+		online[userID] = count.Val() > 0
+	}
+	return online, nil
+}
@@ -0,0 +1,28 @@
+package token
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+const (
+	apiKeyMarker       = "ssk_"
+	apiKeyPrefixLength = 12
+)
+
+// NewAPIKey returns a random API key, the prefix it is listed under and the
+// hash it is stored under. The key itself is only ever shown once.
+func NewAPIKey() (string, string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	apiKey := apiKeyMarker + base64.RawURLEncoding.EncodeToString(buf)
+	return apiKey, apiKey[:apiKeyPrefixLength], HashAPIKey(apiKey), nil
+}
+
+// HashAPIKey hashes an API key the same way refresh tokens are. Keys are
+// random, so an unsalted SHA-256 is enough to make a leaked table useless.
+func HashAPIKey(apiKey string) string {
+	return HashRefreshToken(apiKey)
+}
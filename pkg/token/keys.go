@@ -0,0 +1,114 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minSecretLength is the shortest HS256 secret accepted, matching the hash
+// size.
+const minSecretLength = 32
+
+// Key is one signing key, known by the kid it is published under. A key
+// loaded from a public key only verifies; it is how a retired key keeps old
+// tokens valid until they expire.
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// ParseKey reads key material for alg. HS256 takes the shared secret as is.
+// RS256 and EdDSA take a PEM private key, or a PEM public key for a key that
+// only verifies.
+func ParseKey(id, alg string, material []byte) (*Key, error) {
+	if id == "" {
+		return nil, errors.New("key ID is required")
+	}
+
+	key := &Key{ID: id}
+	switch alg {
+	case "HS256":
+		secret := []byte(strings.TrimSpace(string(material)))
+		if len(secret) < minSecretLength {
+			return nil, fmt.Errorf("key %s: HS256 secret must be at least %d bytes", id, minSecretLength)
+		}
+		key.Method = jwt.SigningMethodHS256
+		key.signKey, key.verifyKey = secret, secret
+	case "RS256":
+		key.Method = jwt.SigningMethodRS256
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(material); err == nil {
+			key.signKey, key.verifyKey = private, &private.PublicKey
+		} else if public, err := jwt.ParseRSAPublicKeyFromPEM(material); err == nil {
+			key.verifyKey = public
+		} else {
+			return nil, fmt.Errorf("key %s: not an RSA key: %w", id, err)
+		}
+	case "EdDSA":
+		key.Method = jwt.SigningMethodEdDSA
+		if private, err := jwt.ParseEdPrivateKeyFromPEM(material); err == nil {
+			key.signKey, key.verifyKey = private, private.(ed25519.PrivateKey).Public()
+		} else if public, err := jwt.ParseEdPublicKeyFromPEM(material); err == nil {
+			key.verifyKey = public
+		} else {
+			return nil, fmt.Errorf("key %s: not an Ed25519 key: %w", id, err)
+		}
+	default:
+		return nil, fmt.Errorf("key %s: unsupported algorithm %q", id, alg)
+	}
+	return key, nil
+}
+
+// CanSign reports whether the key holds a private part.
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+// Keyring signs tokens with one key and verifies them with any key it holds,
+// so the signing key can rotate without invalidating tokens already issued.
+type Keyring struct {
+	signing *Key
+	keys    map[string]*Key
+	order   []string
+}
+
+func NewKeyring(signingKeyID string, keys ...*Key) (*Keyring, error) {
+	ring := &Keyring{keys: make(map[string]*Key, len(keys))}
+	for _, key := range keys {
+		if _, ok := ring.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key ID %q", key.ID)
+		}
+		ring.keys[key.ID] = key
+		ring.order = append(ring.order, key.ID)
+	}
+
+	signing, ok := ring.keys[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q is not configured", signingKeyID)
+	}
+	if !signing.CanSign() {
+		return nil, fmt.Errorf("signing key %q has no private key", signingKeyID)
+	}
+	ring.signing = signing
+	return ring, nil
+}
+
+// verificationKey is the jwt.Keyfunc of the ring. The kid header picks the
+// key, and the token must use that key's algorithm so an RSA public key can
+// never be taken for an HMAC secret.
+func (k *Keyring) verificationKey(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+	return key.verifyKey, nil
+}
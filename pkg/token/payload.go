@@ -1,8 +1,15 @@
 package token
 
-import "time"
+import "github.com/golang-jwt/jwt/v5"
 
+// Token is the claim set of an access token. The user is the standard sub
+// claim; AuthId is that subject parsed back into an ID on validation.
 type Token struct {
-	AuthId  int
-	Expired time.Time
+	Role string `json:"role"`
+	// SessionID is the login the token was issued for. The token stops
+	// working once that session is revoked.
+	SessionID uint64 `json:"sid"`
+	jwt.RegisteredClaims
+
+	AuthId int `json:"-"`
 }
@@ -1,68 +1,68 @@
 package token
 
 import (
-	"encoding/json"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
-	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-const (
-	TOKEN_Key        = "FSUnIQPYBvUeUbo6loiJp4HVk515eA7i"
-	TOKEN_Expiry     = 24 * time.Hour
-	TOKEN_Expiry_B2B = 24 * time.Hour * 365
-)
-
-func GenerateToken(authId int) (string, error) {
-	payload := Token{
-		AuthId:  authId,
-		Expired: time.Now().Add(TOKEN_Expiry),
-	}
-	claims := jwt.MapClaims{
-		"payload": payload,
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, err := token.SignedString([]byte(TOKEN_Key))
+// GenerateToken issues an access token for a session, signed with the ring's
+// signing key. It expires after expiry; the session's refresh token is what
+// gets a new one.
+func (k *Keyring) GenerateToken(authId int, role string, sessionID uint64, expiry time.Duration) (string, error) {
+	tokenID, err := newTokenID()
 	if err != nil {
 		return "", err
 	}
-	return tokenStr, nil
-}
-
-func ValidateToken(tokenString string) (*Token, error) {
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
 
-		return []byte(TOKEN_Key), nil
-	})
+	now := time.Now()
+	claims := Token{
+		Role:      role,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(authId),
+			ID:        tokenID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+		},
+	}
+	token := jwt.NewWithClaims(k.signing.Method, claims)
+	token.Header["kid"] = k.signing.ID
+	return token.SignedString(k.signing.signKey)
+}
 
+// ValidateToken verifies a token against whichever key its kid names and
+// checks its exp and iat claims. Tokens issued for another audience, such as
+// email verification, are refused.
+func (k *Keyring) ValidateToken(tokenString string) (*Token, error) {
+	var claims Token
+	_, err := jwt.ParseWithClaims(tokenString, &claims, k.verificationKey,
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		payloadInterface := claims["payload"]
-
-		payloadToken := Token{}
+	if len(claims.Audience) > 0 {
+		return nil, errors.New("Unauthorized")
+	}
 
-		payloadByte, err := json.Marshal(payloadInterface)
-		if err != nil {
-			return nil, err
-		}
-		err = json.Unmarshal(payloadByte, &payloadToken)
-		if err != nil {
-			return nil, err
-		}
-		now := time.Now()
-		if now.After(payloadToken.Expired) {
-			return nil, errors.New("Token Expired")
-		}
-		return &payloadToken, nil
-	} else {
+	claims.AuthId, err = strconv.Atoi(claims.Subject)
+	if err != nil || claims.AuthId <= 0 {
 		return nil, errors.New("Unauthorized")
 	}
+	return &claims, nil
+}
+
+func newTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
 }
@@ -0,0 +1,184 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func pemBlock(t *testing.T, blockType string, der []byte, err error) []byte {
+	t.Helper()
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+func rsaKeys(t *testing.T) (private, public []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privateDER, err := x509.MarshalPKCS8PrivateKey(key)
+	private = pemBlock(t, "PRIVATE KEY", privateDER, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	public = pemBlock(t, "PUBLIC KEY", publicDER, err)
+	return private, public
+}
+
+func edKeys(t *testing.T) (private, public []byte) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	private = pemBlock(t, "PRIVATE KEY", privateDER, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	public = pemBlock(t, "PUBLIC KEY", publicDER, err)
+	return private, public
+}
+
+func mustKey(t *testing.T, id, alg string, material []byte) *Key {
+	t.Helper()
+	key, err := ParseKey(id, alg, material)
+	require.NoError(t, err)
+	return key
+}
+
+func TestTokenRoundTripsForEveryAlgorithm(t *testing.T) {
+	rsaPrivate, _ := rsaKeys(t)
+	edPrivate, _ := edKeys(t)
+
+	for alg, material := range map[string][]byte{
+		"HS256": []byte(testSecret),
+		"RS256": rsaPrivate,
+		"EdDSA": edPrivate,
+	} {
+		t.Run(alg, func(t *testing.T) {
+			ring, err := NewKeyring("k1", mustKey(t, "k1", alg, material))
+			require.NoError(t, err)
+
+			tokenStr, err := ring.GenerateToken(42, "admin", 7, time.Minute)
+			require.NoError(t, err)
+
+			claims, err := ring.ValidateToken(tokenStr)
+			require.NoError(t, err)
+			require.Equal(t, 42, claims.AuthId)
+			require.Equal(t, "42", claims.Subject)
+			require.Equal(t, "admin", claims.Role)
+			require.Equal(t, uint64(7), claims.SessionID)
+			require.NotEmpty(t, claims.ID)
+			require.NotNil(t, claims.IssuedAt)
+		})
+	}
+}
+
+func TestRotatedKeyStillVerifiesOldTokens(t *testing.T) {
+	oldPrivate, oldPublic := rsaKeys(t)
+	newPrivate, _ := edKeys(t)
+
+	before, err := NewKeyring("2023", mustKey(t, "2023", "RS256", oldPrivate))
+	require.NoError(t, err)
+	oldToken, err := before.GenerateToken(1, "user", 1, time.Minute)
+	require.NoError(t, err)
+
+	after, err := NewKeyring("2024",
+		mustKey(t, "2023", "RS256", oldPublic),
+		mustKey(t, "2024", "EdDSA", newPrivate),
+	)
+	require.NoError(t, err)
+
+	_, err = after.ValidateToken(oldToken)
+	require.NoError(t, err)
+
+	newToken, err := after.GenerateToken(1, "user", 1, time.Minute)
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &Token{})
+	require.NoError(t, err)
+	require.Equal(t, "2024", parsed.Header["kid"])
+
+	_, err = before.ValidateToken(newToken)
+	require.Error(t, err)
+}
+
+func TestValidateTokenRejectsExpiredAndForeignTokens(t *testing.T) {
+	ring, err := NewKeyring("k1", mustKey(t, "k1", "HS256", []byte(testSecret)))
+	require.NoError(t, err)
+
+	expired, err := ring.GenerateToken(1, "user", 1, -time.Minute)
+	require.NoError(t, err)
+	_, err = ring.ValidateToken(expired)
+	require.ErrorIs(t, err, jwt.ErrTokenExpired)
+
+	foreign := jwt.NewWithClaims(jwt.SigningMethodHS256, Token{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "1",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	foreign.Header["kid"] = "k1"
+	forged, err := foreign.SignedString([]byte("another secret that is long enough"))
+	require.NoError(t, err)
+	_, err = ring.ValidateToken(forged)
+	require.Error(t, err)
+
+	foreign.Header["kid"] = "unknown"
+	unknownKid, err := foreign.SignedString([]byte(testSecret))
+	require.NoError(t, err)
+	_, err = ring.ValidateToken(unknownKid)
+	require.Error(t, err)
+}
+
+func TestKeyringRefusesVerifyOnlySigningKey(t *testing.T) {
+	_, public := edKeys(t)
+	_, err := NewKeyring("k1", mustKey(t, "k1", "EdDSA", public))
+	require.Error(t, err)
+
+	_, err = ParseKey("k2", "HS256", []byte("short"))
+	require.Error(t, err)
+}
+
+func TestJWKSPublishesOnlyPublicKeys(t *testing.T) {
+	rsaPrivate, _ := rsaKeys(t)
+	_, edPublic := edKeys(t)
+
+	ring, err := NewKeyring("hmac",
+		mustKey(t, "hmac", "HS256", []byte(testSecret)),
+		mustKey(t, "rsa", "RS256", rsaPrivate),
+		mustKey(t, "ed", "EdDSA", edPublic),
+	)
+	require.NoError(t, err)
+
+	set := ring.JWKS()
+	require.Len(t, set.Keys, 2)
+	require.Equal(t, "rsa", set.Keys[0].Kid)
+	require.Equal(t, "RSA", set.Keys[0].Kty)
+	require.Equal(t, "AQAB", set.Keys[0].E)
+	require.Equal(t, "ed", set.Keys[1].Kid)
+	require.Equal(t, "OKP", set.Keys[1].Kty)
+	require.Equal(t, "Ed25519", set.Keys[1].Crv)
+}
+
+func TestEmailTokensAndAccessTokensAreNotInterchangeable(t *testing.T) {
+	ring, err := NewKeyring("k1", mustKey(t, "k1", "HS256", []byte(testSecret)))
+	require.NoError(t, err)
+
+	emailToken, err := ring.GenerateEmailToken(42, "jane@example.com", time.Minute)
+	require.NoError(t, err)
+
+	claims, err := ring.ValidateEmailToken(emailToken)
+	require.NoError(t, err)
+	require.Equal(t, 42, claims.AuthId)
+	require.Equal(t, "jane@example.com", claims.Email)
+
+	_, err = ring.ValidateToken(emailToken)
+	require.Error(t, err)
+
+	accessToken, err := ring.GenerateToken(42, "user", 1, time.Minute)
+	require.NoError(t, err)
+	_, err = ring.ValidateEmailToken(accessToken)
+	require.Error(t, err)
+}